@@ -0,0 +1,92 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// annotations is a set of annotations parsed from doc comments.
+type annotations struct {
+	keep, exclude, root, precompute bool
+
+	keepMethods bool
+	methods     []string // names of methods to keep. all methods if empty
+}
+
+// parseAnnotations parses annotations from doc comments.
+// Unknown annotations are reported as warnings.
+func parseAnnotations(docs ...*ast.CommentGroup) (a annotations) {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		for _, c := range doc.List {
+			if !strings.HasPrefix(c.Text, annotationPrefix) {
+				continue
+			}
+
+			fields := strings.Fields(strings.TrimPrefix(c.Text, annotationPrefix))
+			switch {
+			case len(fields) >= 2 && annotationPrefix+fields[0]+" "+fields[1] == keepMethods.String():
+				a.keepMethods = true
+				a.methods = append(a.methods, fields[2:]...)
+			case len(fields) == 1 && annotationPrefix+fields[0] == keep.String():
+				a.keep = true
+			case len(fields) == 1 && annotationPrefix+fields[0] == exclude.String():
+				a.exclude = true
+			case len(fields) == 1 && annotationPrefix+fields[0] == root.String():
+				a.root = true
+			case len(fields) == 1 && annotationPrefix+fields[0] == precompute.String():
+				a.precompute = true
+			default:
+				color.New(color.FgYellow).Fprintf(
+					WarnOutput,
+					"[warn] Unknown annotation `%s`\n", c.Text,
+				)
+			}
+		}
+	}
+	return
+}
+
+// isAnnotation returns true if the comment is a gollect annotation.
+func isAnnotation(c *ast.Comment) bool {
+	return strings.Contains(c.Text, annotationPrefix)
+}
+
+// removeAnnotations removes annotation comments from the doc.
+func removeAnnotations(doc *ast.CommentGroup) { removeCommentsIf(doc, isAnnotation) }
+
+// removeCommentsIf removes the comments satisfying f from the doc.
+// Empty lines left at the tail are removed as well, since they
+// separated the removed comments from the doc text.
+func removeCommentsIf(doc *ast.CommentGroup, f func(c *ast.Comment) bool) {
+	if doc == nil {
+		return
+	}
+	docs := make([]*ast.Comment, len(doc.List))
+	i := 0
+	for _, c := range doc.List {
+		if !f(c) {
+			docs[i] = c
+			i++
+		}
+	}
+	for i > 0 && i < len(doc.List) && docs[i-1].Text == "//" {
+		i--
+	}
+
+	// move the comments left to the tail positions, so that no blank
+	// line is left between the doc and the declaration.
+	for j, c := range docs[:i] {
+		c.Slash = doc.List[len(doc.List)-i+j].Slash
+	}
+	doc.List = docs[:i]
+}
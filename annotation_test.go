@@ -0,0 +1,70 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"go/ast"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	prev := WarnOutput
+	WarnOutput = io.Discard
+	defer func() { WarnOutput = prev }()
+
+	cases := []struct {
+		in   []string
+		want annotations
+	}{
+		{in: []string{"// comment"}, want: annotations{}},
+		{in: []string{"// gollect: keep"}, want: annotations{keep: true}},
+		{in: []string{"// gollect: exclude"}, want: annotations{exclude: true}},
+		{in: []string{"// gollect: root"}, want: annotations{root: true}},
+		{in: []string{"// gollect: keep methods"}, want: annotations{keepMethods: true}},
+		{
+			in:   []string{"// doc", "// gollect: keep methods Len Less Swap"},
+			want: annotations{keepMethods: true, methods: []string{"Len", "Less", "Swap"}},
+		},
+		{in: []string{"// gollect: unknown"}, want: annotations{}},
+	}
+
+	for i, c := range cases {
+		doc := &ast.CommentGroup{}
+		for _, text := range c.in {
+			doc.List = append(doc.List, &ast.Comment{Text: text})
+		}
+
+		if actual := parseAnnotations(doc, nil); !reflect.DeepEqual(actual, c.want) {
+			t.Errorf("at: %d, want: %+v, actual: %+v", i, c.want, actual)
+		}
+	}
+}
+
+func TestRemoveAnnotations(t *testing.T) {
+	doc := &ast.CommentGroup{List: []*ast.Comment{
+		{Slash: 1, Text: "// doc"},
+		{Slash: 10, Text: "// gollect: keep"},
+	}}
+	removeAnnotations(doc)
+
+	if len(doc.List) != 1 || doc.List[0].Text != "// doc" || doc.List[0].Slash != 10 {
+		t.Errorf("unexpected comments: %v", doc.List)
+	}
+}
+
+func TestExclude(t *testing.T) {
+	program := NewProgram()
+	paths, _ := filepath.Glob(testdata.FilePaths.Exclude)
+	ParseAll(program, "main", paths)
+
+	shouldPanic(t, func() {
+		AnalyzeForeach(program, "main", "main")
+	}, "should fail because excluded function is used")
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// buildTag is a build tag always satisfied when bundling.
+// Files constrained by `//go:build !gollect` are used only locally,
+// and files constrained by `//go:build gollect` only in the submission.
+const buildTag = "gollect"
+
+var (
+	// the original context of go/build.Default
+	defaultBuildContext = build.Default
+
+	// context used to select files of packages.
+	buildContext = newBuildContext("", "")
+)
+
+func newBuildContext(goos, goarch string) build.Context {
+	ctx := defaultBuildContext
+	if goos != "" {
+		ctx.GOOS = goos
+	}
+	if goarch != "" {
+		ctx.GOARCH = goarch
+	}
+	ctx.BuildTags = append(append([]string{}, ctx.BuildTags...), buildTag)
+	return ctx
+}
+
+// !! this function populates global variables including go/build.Default !!
+// go/build.Default is used by the source importer for type checking.
+func setBuildContext(goos, goarch string) {
+	buildContext = newBuildContext(goos, goarch)
+	build.Default = buildContext
+}
+
+// MatchBuildContext returns the files which match build constraints
+// of target GOOS, GOARCH and the gollect build tag, as same as go build.
+// Test files are also excluded.
+func MatchBuildContext(paths []string) (res []string, err error) {
+	for _, path := range paths {
+		dir, name := filepath.Split(path)
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		ok, err := buildContext.MatchFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, path)
+		}
+	}
+	return
+}
+
+// buildEnv returns environment variables for go command.
+func buildEnv() []string {
+	return append(
+		os.Environ(),
+		"GOOS="+buildContext.GOOS,
+		"GOARCH="+buildContext.GOARCH,
+	)
+}
+
+// buildFlags returns flags for go command.
+func buildFlags() []string {
+	return []string{"-tags=" + strings.Join(buildContext.BuildTags, ",")}
+}
@@ -0,0 +1,638 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/tools/go/gcexportdata"
+)
+
+// cacheVersion must be changed when the format of cache entry changes.
+const cacheVersion = "6"
+
+// PackageCache is an on-disk cache of analyzed library packages.
+//
+// An entry is keyed by package path, contents of its files, Go version
+// and the keys of imported packages. It stores the declaration table built
+// by DeclFinder, the dependency edges of each declaration and the export
+// data of the types, so cached packages are neither analyzed nor
+// type-checked again. The packages importing them read the export data.
+//
+// Entries are also kept in memory, so a long-lived cache shared by
+// several programs does not read them from disk again.
+// The nil value is a valid cache that never hits.
+type PackageCache struct {
+	dir     string
+	mem     map[string]*cacheEntry // entry key → entry
+	files   map[string][]string    // package path → file paths
+	keys    map[string]string      // package path → entry key, per analysis
+	entries map[string]*cacheEntry // package path → loaded entry, per analysis
+}
+
+type (
+	cacheEntry struct {
+		Decls    []cacheDecl    `json:"decls"`
+		PkgNames []cachePkgName `json:"pkgNames,omitempty"`
+		Stripped []cacheStrip   `json:"stripped,omitempty"`
+		Export   []byte         `json:"export"`
+	}
+
+	cacheDecl struct {
+		Type        DeclType      `json:"type"`
+		Keys        []string      `json:"keys"`
+		Kept        bool          `json:"kept,omitempty"`
+		Excluded    bool          `json:"excluded,omitempty"`
+		Alias       AliasKind     `json:"alias,omitempty"`
+		Keep        bool          `json:"keep,omitempty"` // keep all methods
+		KeepMethods []string      `json:"keepMethods,omitempty"`
+		Embedded    bool          `json:"embedded,omitempty"`
+		Uses        []cacheRef    `json:"uses,omitempty"`
+		Imports     []cacheImport `json:"imports,omitempty"`
+	}
+
+	cacheRef struct {
+		Path string   `json:"path"`
+		Keys []string `json:"keys"`
+	}
+
+	cacheImport struct {
+		Alias string `json:"alias,omitempty"`
+		Name  string `json:"name"`
+		Path  string `json:"path"`
+	}
+
+	// cachePos is a position in the package files.
+	cachePos struct {
+		File   int `json:"file"`
+		Offset int `json:"offset"`
+	}
+
+	cacheStrip struct {
+		cachePos
+		Blank []string `json:"blank,omitempty"` // locals assigned to blank
+	}
+
+	// cachePkgName is an identifier refers to an imported package.
+	// It is restored to types.Info.Uses to strip package selectors.
+	cachePkgName struct {
+		File   int    `json:"file"`
+		Offset int    `json:"offset"`
+		Name   string `json:"name"`
+		Path   string `json:"path"`
+	}
+)
+
+// NewPackageCache returns new PackageCache stores entries to dir.
+// If dir is empty, entries are kept only in memory.
+func NewPackageCache(dir string) *PackageCache {
+	return &PackageCache{
+		dir:     dir,
+		mem:     make(map[string]*cacheEntry),
+		files:   make(map[string][]string),
+		keys:    make(map[string]string),
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// DefaultCacheDir returns the default directory of PackageCache.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gollect"), nil
+}
+
+// Load restores the declarations of the package from cache.
+// It returns false if there is no available entry.
+// Dependency edges are not restored until Link is called.
+func (c *PackageCache) Load(program *Program, pkg *Package) bool {
+	if c == nil {
+		return false
+	}
+
+	fset, dset := program.FileSet(), program.DeclSet()
+	key, err := c.key(program, pkg)
+	if err != nil {
+		return false
+	}
+
+	e, ok := c.mem[key]
+	if !ok {
+		if c.dir == "" {
+			return false
+		}
+
+		b, err := os.ReadFile(c.path(key))
+		if err != nil {
+			return false
+		}
+
+		e = &cacheEntry{}
+		if err := json.Unmarshal(b, e); err != nil {
+			return false
+		}
+		c.mem[key] = e
+	}
+
+	ids := make([][]string, len(e.Decls))
+	for i, d := range e.Decls {
+		keys, err := absKeys(fset, pkg, d.Keys)
+		if err != nil {
+			return false
+		}
+		ids[i] = keys
+	}
+
+	for i, d := range e.Decls {
+		dset.GetOrCreate(d.Type, pkg, ids[i]...)
+	}
+
+	for i, d := range e.Decls {
+		keys := ids[i]
+		decl, _ := dset.Get(pkg, keys...)
+
+		if ad, ok := decl.(interface {
+			Keep()
+			Exclude()
+		}); ok {
+			if d.Kept {
+				ad.Keep()
+			}
+			if d.Excluded {
+				ad.Exclude()
+			}
+		}
+
+		switch decl := decl.(type) {
+		case *TypeDecl:
+			decl.SetAlias(d.Alias)
+			if d.Keep {
+				decl.KeepMethod()
+			}
+			if len(d.KeepMethods) > 0 {
+				decl.KeepMethod(d.KeepMethods...)
+			}
+		case *MethodDecl:
+			decl.SetEmbedded(d.Embedded)
+			if t, ok := dset.Get(pkg, keys[0]); ok {
+				decl.SetType(t.(*TypeDecl))
+				decl.Type().SetMethod(decl)
+			}
+		}
+	}
+
+	pkg.cached = true
+	pkg.export = e.Export
+	pkg.deps = make(map[string][]*Import)
+	for i, d := range e.Decls {
+		id := makeID(pkg, ids[i]...)
+		for _, imp := range d.Imports {
+			pkg.deps[id] = append(pkg.deps[id], NewImport(imp.Alias, imp.Name, imp.Path))
+		}
+	}
+
+	restorePkgNames(fset, pkg, e.PkgNames)
+	restoreStripped(fset, pkg, e.Stripped)
+	attachNodes(dset, pkg)
+
+	c.entries[pkg.path] = e
+	return true
+}
+
+// Link restores dependency edges of loaded packages.
+// This must be called after all packages' declarations are found.
+func (c *PackageCache) Link(program *Program) {
+	if c == nil {
+		return
+	}
+
+	fset, dset, pset := program.FileSet(), program.DeclSet(), program.PackageSet()
+
+	for path, e := range c.entries {
+		pkg, _ := pset.Get(path)
+		for _, d := range e.Decls {
+			keys, _ := absKeys(fset, pkg, d.Keys)
+			decl, _ := dset.Get(pkg, keys...)
+
+			for _, ref := range d.Uses {
+				rpkg, ok := pset.Get(ref.Path)
+				if !ok {
+					continue
+				}
+				rkeys, err := absKeys(fset, rpkg, ref.Keys)
+				if err != nil {
+					continue
+				}
+				if used, ok := dset.Get(rpkg, rkeys...); ok {
+					decl.Uses(used)
+				}
+			}
+		}
+	}
+}
+
+// Store saves the analyzed declarations of the package.
+// This must be called before resolving dependencies, because
+// DependencyResolver appends edges to declarations lazily.
+func (c *PackageCache) Store(program *Program, pkg *Package) {
+	if c == nil {
+		return
+	}
+
+	if err := c.store(program, pkg); err != nil {
+		color.New(color.FgYellow).Fprintf(
+			WarnOutput,
+			"[warn] Failed to cache the package `%s`: %v\n", pkg.path, err,
+		)
+	}
+}
+
+func (c *PackageCache) store(program *Program, pkg *Package) error {
+	fset, dset, pset := program.FileSet(), program.DeclSet(), program.PackageSet()
+	key, err := c.key(program, pkg)
+	if err != nil {
+		return err
+	}
+
+	var decls []Decl
+	dset.Each(func(decl Decl) {
+		if decl.Pkg() == pkg {
+			decls = append(decls, decl)
+		}
+	})
+	sort.Slice(decls, func(i, j int) bool { return decls[i].ID() < decls[j].ID() })
+
+	if pkg.types == nil {
+		return fmt.Errorf("package %s is not type-checked", pkg.path)
+	}
+	var export bytes.Buffer
+	if err := gcexportdata.Write(&export, fset, pkg.types); err != nil {
+		return err
+	}
+
+	r := NewDependencyResolver(dset, nil, pset)
+	e := &cacheEntry{Export: export.Bytes()}
+
+	for _, decl := range decls {
+		d := cacheDecl{
+			Keys:     relKeys(fset, pkg, declKeys(decl)),
+			Kept:     decl.IsKept(),
+			Excluded: decl.IsExcluded(),
+		}
+
+		switch decl := decl.(type) {
+		case *CommonDecl:
+			d.Type = DecCommon
+		case *TypeDecl:
+			d.Type = DecType
+			d.Alias = decl.Alias()
+			d.Keep = decl.ShouldKeepMethods()
+			d.KeepMethods = decl.KeptMethodNames()
+		case *MethodDecl:
+			d.Type = DecMethod
+			d.Embedded = decl.IsEmbedded()
+		}
+
+		seen := make(map[string]bool)
+		ref := func(used Decl) {
+			if seen[used.ID()] {
+				return
+			}
+			seen[used.ID()] = true
+			d.Uses = append(d.Uses, cacheRef{
+				Path: used.Pkg().Path(),
+				Keys: relKeys(fset, used.Pkg(), declKeys(used)),
+			})
+		}
+
+		decl.GetUses().Each(ref)
+		r.inspect(decl, ref, func(alias, name, path string) {
+			d.Imports = append(d.Imports, cacheImport{Alias: alias, Name: name, Path: path})
+		})
+
+		e.Decls = append(e.Decls, d)
+	}
+
+	for i, file := range pkg.files {
+		tf := fset.File(file.Pos())
+		ast.Inspect(file, func(node ast.Node) bool {
+			id, ok := node.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if pn, ok := pkg.Info().Uses[id].(*types.PkgName); ok {
+				e.PkgNames = append(e.PkgNames, cachePkgName{
+					File:   i,
+					Offset: tf.Offset(id.Pos()),
+					Name:   pn.Imported().Name(),
+					Path:   pn.Imported().Path(),
+				})
+			}
+			return true
+		})
+	}
+
+	for _, s := range pkg.stripped {
+		tf := fset.File(s.pos)
+		for i, file := range pkg.files {
+			if fset.File(file.Pos()) == tf {
+				e.Stripped = append(e.Stripped, cacheStrip{
+					cachePos: cachePos{File: i, Offset: tf.Offset(s.pos)},
+					Blank:    s.blank,
+				})
+			}
+		}
+	}
+
+	c.mem[key] = e
+	if c.dir == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Begin starts new analysis. The keys computed by previous analysis
+// are discarded because the files may have been changed since then.
+func (c *PackageCache) Begin() {
+	if c == nil {
+		return
+	}
+	c.keys = make(map[string]string)
+	c.entries = make(map[string]*cacheEntry)
+}
+
+// FilePaths returns file paths of the package.
+// The result is memorized until Invalidate is called.
+func (c *PackageCache) FilePaths(path string) []string {
+	if c == nil {
+		return FindFilePaths(path)
+	}
+
+	if paths, ok := c.files[path]; ok {
+		return paths
+	}
+
+	paths := FindFilePaths(path)
+	c.files[path] = paths
+	return paths
+}
+
+// Invalidate forgets the file paths of the packages that contain
+// any of given files or directories. If no path is given, all
+// packages are forgotten. It returns package paths invalidated.
+//
+// Entries need not to be invalidated because they are keyed by
+// contents of files.
+func (c *PackageCache) Invalidate(paths ...string) (invalidated []string) {
+	if c == nil {
+		return nil
+	}
+
+	for pkg, files := range c.files {
+		if len(paths) == 0 || containsAny(files, paths) {
+			delete(c.files, pkg)
+			invalidated = append(invalidated, pkg)
+		}
+	}
+	sort.Strings(invalidated)
+	return
+}
+
+func containsAny(files, paths []string) bool {
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		for _, p := range paths {
+			p = filepath.Clean(p)
+			if p == file || p == dir || filepath.Dir(p) == dir {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *PackageCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// key returns the entry key of the package.
+// The keys of imported packages are included so that the entry is
+// invalidated when one of its dependencies changes.
+func (c *PackageCache) key(program *Program, pkg *Package) (string, error) {
+	fset, pset := program.FileSet(), program.PackageSet()
+	if key, ok := c.keys[pkg.path]; ok {
+		return key, nil
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", cacheVersion, runtime.Version(), pkg.path)
+	fmt.Fprintf(h, "%q\n", thirdPartyPackagePathPrefixes)
+	fmt.Fprintf(h, "%s\n", program.Stripper())
+	fmt.Fprintf(h, "%s\n", program.Precomputer())
+
+	for _, file := range pkg.files {
+		name := fset.File(file.Pos()).Name()
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", filepath.Base(name), len(b))
+		h.Write(b)
+	}
+
+	deps := NextPackagePaths(pkg)
+	sort.Strings(deps)
+	for _, path := range deps {
+		dep, ok := pset.Get(path)
+		if !ok {
+			continue
+		}
+		key, err := c.key(program, dep)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %s\n", path, key)
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	c.keys[pkg.path] = key
+	return key, nil
+}
+
+// declKeys returns the keys the decl is created with.
+func declKeys(decl Decl) []string {
+	s := strings.TrimPrefix(decl.ID(), decl.Pkg().Path()+sep)
+	if isUnderscore(s) {
+		return []string{s}
+	}
+	return strings.Split(s, sep)
+}
+
+// relKeys converts underscore keys, which contain an absolute position
+// of the FileSet, to the file index and offset in the file.
+func relKeys(fset *token.FileSet, pkg *Package, keys []string) []string {
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = k
+		if !isUnderscore(k) {
+			continue
+		}
+
+		n, _ := strconv.Atoi(strings.TrimPrefix(k, "_"+sep))
+		pos := token.Pos(n)
+		tf := fset.File(pos)
+		for j, file := range pkg.files {
+			if fset.File(file.Pos()) == tf {
+				res[i] = fmt.Sprintf("_%s%d:%d", sep, j, tf.Offset(pos))
+			}
+		}
+	}
+	return res
+}
+
+// absKeys is the inverse of relKeys.
+func absKeys(fset *token.FileSet, pkg *Package, keys []string) ([]string, error) {
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = k
+		if !isUnderscore(k) {
+			continue
+		}
+
+		var j, offset int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(k, "_"+sep), "%d:%d", &j, &offset); err != nil {
+			return nil, err
+		}
+		if j < 0 || j >= len(pkg.files) {
+			return nil, fmt.Errorf("file index out of range: %d", j)
+		}
+		pos := fset.File(pkg.files[j].Pos()).Pos(offset)
+		res[i] = "_" + sep + fmt.Sprint(int(pos))
+	}
+	return res, nil
+}
+
+func restorePkgNames(fset *token.FileSet, pkg *Package, names []cachePkgName) {
+	for i, file := range pkg.files {
+		tf := fset.File(file.Pos())
+		m := make(map[int]cachePkgName)
+		for _, n := range names {
+			if n.File == i {
+				m[n.Offset] = n
+			}
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			id, ok := node.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if n, ok := m[tf.Offset(id.Pos())]; ok {
+				imported := types.NewPackage(n.Path, n.Name)
+				pkg.Info().Uses[id] = types.NewPkgName(id.Pos(), nil, id.Name, imported)
+			}
+			return true
+		})
+	}
+}
+
+// restoreStripped removes the statements Stripper removed when the
+// package was stored.
+func restoreStripped(fset *token.FileSet, pkg *Package, stripped []cacheStrip) {
+	if len(stripped) == 0 {
+		return
+	}
+
+	blanks := make(map[token.Pos][]string)
+	for _, s := range stripped {
+		if s.File >= 0 && s.File < len(pkg.files) {
+			blanks[fset.File(pkg.files[s.File].Pos()).Pos(s.Offset)] = s.Blank
+		}
+	}
+
+	pkg.stripped = stripStmts(pkg.files, func(stmt ast.Stmt) bool {
+		_, ok := blanks[stmt.Pos()]
+		return ok
+	}, func(stmt ast.Stmt) (ids []*ast.Ident) {
+		for _, name := range blanks[stmt.Pos()] {
+			ids = append(ids, ast.NewIdent(name))
+		}
+		return
+	})
+}
+
+// attachNodes sets ast nodes to the declarations restored from cache.
+func attachNodes(dset DeclSet, pkg *Package) {
+	set := func(n ast.Node, keys ...string) {
+		if d, ok := dset.Get(pkg, keys...); ok {
+			d.SetNode(n)
+		}
+	}
+
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							set(spec, nameForUnderscore(id))
+						}
+					case *ast.TypeSpec:
+						set(spec, spec.Name.Name)
+					}
+				}
+
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					set(decl, decl.Name.Name)
+				} else if id := receiverID(decl.Recv.List[0].Type); id != nil {
+					set(decl, id.Name, decl.Name.Name)
+				}
+			}
+		}
+	}
+}
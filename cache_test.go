@@ -0,0 +1,73 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestPackageCache(t *testing.T) {
+	setThirdPartyPackagePathPrefixes([]string{"golang.org/x/exp"})
+
+	for i, tc := range testdata.Cases {
+		dir := t.TempDir()
+
+		conf := caseConfig(t, tc)
+
+		bundle := func() (*Program, string) {
+			program := newProgram(conf)
+			program.SetPackageCache(NewPackageCache(dir))
+
+			if err := parse(program, conf); err != nil {
+				t.Fatalf("At: %d, %v", i, err)
+			}
+			if err := analyze(program, conf); err != nil {
+				t.Fatalf("At: %d, %v", i, err)
+			}
+
+			var buf bytes.Buffer
+			if err := Write(&buf, program); err != nil {
+				t.Fatalf("At: %d, %v", i, err)
+			}
+			return program, buf.String()
+		}
+
+		first, want := bundle()
+		for path, pkg := range first.PackageSet() {
+			if pkg.IsCached() {
+				t.Errorf("At: %d, package %s should not be cached at first", i, path)
+			}
+		}
+
+		second, actual := bundle()
+		for path, pkg := range second.PackageSet() {
+			// minifying does not load the cache
+			want := path != second.EntryPackage() && !conf.Minify
+			if cached := pkg.IsCached(); cached != want {
+				t.Errorf("At: %d, package %s cached=%t", i, path, cached)
+			}
+			// cached packages are read from export data
+			if want && len(pkg.Info().Defs) > 0 {
+				t.Errorf("At: %d, package %s should not be type-checked", i, path)
+			}
+		}
+
+		if want != actual {
+			diff := dmp.New().DiffMain(want, actual, true)
+			t.Errorf("\n[at] %d\n[diff]\n%s", i, colorDiff(diff))
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != len(first.PackageSet())-1 {
+			t.Errorf("At: %d, number of entries=%d", i, len(entries))
+		}
+	}
+}
@@ -0,0 +1,374 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+)
+
+type (
+	// CheckOptions configures Check.
+	CheckOptions struct {
+		// filepath of the expected output relative to the directory of
+		// each solution. The bundle is not compared if empty or the
+		// file does not exist.
+		Expected string
+
+		// writes the bundles to the expected files instead of comparing.
+		Update bool
+
+		// builds the bundles with go command.
+		Build bool
+
+		// glob of sample inputs relative to the directory of each
+		// solution. The expected output of "x.in" is "x.out".
+		// Samples are run only when Build is true.
+		Samples string
+
+		// time limit of running each sample. 0 means unlimited.
+		Timeout time.Duration
+	}
+
+	// CheckReport is the result of Check.
+	CheckReport struct {
+		Results []CheckResult
+	}
+
+	// CheckResult is the result of a solution.
+	CheckResult struct {
+		Dir    string
+		Errors []string // empty if passed
+	}
+)
+
+// Check bundles every main package under the root directory, and
+// checks the bundles are type-checked, same as the expected output,
+// and optionally built and pass the samples.
+// The config is used for each of them, except for the input file and
+// output paths.
+func Check(config *Config, root string, opts CheckOptions) (*CheckReport, error) {
+	setBuildContext(config.GOOS, config.GOARCH)
+
+	dirs, err := findSolutions(root)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := newImporter()
+	r := &CheckReport{}
+	for _, dir := range dirs {
+		c := *config
+		c.InputFile = filepath.Join(dir, "*.go")
+
+		// reset, since //gollect:allow of the previous solution
+		// changes the prefixes.
+		setThirdPartyPackagePathPrefixes(c.ThirdPartyPackagePathPrefixes)
+		c.OutputPaths = nil
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+
+		res := CheckResult{Dir: dir}
+		for _, err := range checkSolution(&c, dir, imp, opts) {
+			res.Errors = append(res.Errors, err.Error())
+		}
+		r.Results = append(r.Results, res)
+	}
+	return r, nil
+}
+
+// checkSolution bundles the main package and checks it.
+func checkSolution(config *Config, dir string, imp types.Importer, opts CheckOptions) []error {
+	src, err := bundleSolution(config, imp)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	if opts.Expected != "" {
+		if err := checkExpected(src, filepath.Join(dir, opts.Expected), opts.Update); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if config.Snippet {
+		src = append([]byte("package main\n\n"), src...)
+	}
+	if terrs := typeCheck(src, dir, imp); len(terrs) > 0 {
+		// building fails as well
+		return append(errs, terrs...)
+	}
+
+	if opts.Build {
+		errs = append(errs, buildAndRun(src, dir, opts)...)
+	}
+	return errs
+}
+
+// bundleSolution bundles the main package. Panics while analyzing are
+// returned as errors.
+func bundleSolution(config *Config, imp types.Importer) (src []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("bundle: %v", r)
+		}
+	}()
+
+	p := newProgram(config)
+	p.SetImporter(imp)
+
+	var buf bytes.Buffer
+	if err := bundle(p, config, &buf); err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	if err := checkSize(p, config, buf.Len()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checkExpected compares the bundle with the expected output, or
+// writes the bundle if update is true.
+func checkExpected(src []byte, path string, update bool) error {
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, src, 0o644)
+	}
+
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if line := firstDiffLine(expected, src); line > 0 {
+		return fmt.Errorf("differs from %s at line %d", filepath.Base(path), line)
+	}
+	return nil
+}
+
+// firstDiffLine returns the line number where a and b differ first.
+// It returns 0 if they are same.
+func firstDiffLine(a, b []byte) int {
+	if bytes.Equal(a, b) {
+		return 0
+	}
+
+	la, lb := bytes.SplitAfter(a, []byte("\n")), bytes.SplitAfter(b, []byte("\n"))
+	for i := 0; ; i++ {
+		if i >= len(la) || i >= len(lb) || !bytes.Equal(la[i], lb[i]) {
+			return i + 1
+		}
+	}
+}
+
+// typeCheck type-checks the bundle as a file in the directory, so that
+// imports are resolved with the module of the directory.
+func typeCheck(src []byte, dir string, imp types.Importer) []error {
+	// at most 10 errors like go command
+	const max = 10
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(dir, "gollect_bundle.go"), src, 0)
+	if err != nil {
+		return []error{fmt.Errorf("parse: %w", err)}
+	}
+
+	var errs []error
+	conf := &types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			if len(errs) < max {
+				errs = append(errs, fmt.Errorf("type check: %s", stripDir(err.Error(), dir)))
+			}
+		},
+	}
+	_, _ = conf.Check("main", fset, []*ast.File{file}, nil)
+	return errs
+}
+
+// buildAndRun builds the bundle in a temporary directory with the
+// requirements of the module of dir, and runs the samples.
+func buildAndRun(src []byte, dir string, opts CheckOptions) []error {
+	tmp, err := os.MkdirTemp("", "gollect-check-")
+	if err != nil {
+		return []error{err}
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), src, 0o644); err != nil {
+		return []error{err}
+	}
+	if err := writeModule(dir, tmp); err != nil {
+		return []error{err}
+	}
+
+	bin := filepath.Join(tmp, "main")
+	args := append([]string{"build", "-o", bin}, buildFlags()...)
+	cmd := exec.Command("go", append(args, ".")...)
+	cmd.Dir = tmp
+	cmd.Env = buildEnv()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return []error{fmt.Errorf("build: %w\n%s", err, strings.TrimSpace(stripDir(string(out), tmp)))}
+	}
+
+	if opts.Samples == "" {
+		return nil
+	}
+	inputs, err := filepath.Glob(filepath.Join(dir, opts.Samples))
+	if err != nil {
+		return []error{fmt.Errorf("samples: %w", err)}
+	}
+
+	var errs []error
+	for _, in := range inputs {
+		if err := runSample(bin, in, opts.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("sample %s: %w", filepath.Base(in), err))
+		}
+	}
+	return errs
+}
+
+// writeModule writes go.mod and go.sum of the module containing dir
+// into tmp. Relative paths of replace directives are made absolute.
+func writeModule(dir, tmp string) error {
+	root, _ := findModule(dir)
+	if root == "" {
+		return os.WriteFile(filepath.Join(tmp, "go.mod"), []byte("module main\n"), 0o644)
+	}
+
+	gomod := filepath.Join(root, "go.mod")
+	b, err := os.ReadFile(gomod)
+	if err != nil {
+		return err
+	}
+	f, err := modfile.Parse(gomod, b, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range f.Replace {
+		if r.New.Version == "" && !filepath.IsAbs(r.New.Path) {
+			path := filepath.Join(root, r.New.Path)
+			if err := f.AddReplace(r.Old.Path, r.Old.Version, path, ""); err != nil {
+				return err
+			}
+		}
+	}
+	if b, err = f.Format(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), b, 0o644); err != nil {
+		return err
+	}
+
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(tmp, "go.sum"), sum, 0o644)
+}
+
+// runSample runs the binary with the input, and compares the output
+// with the ".out" file ignoring trailing spaces of each line.
+func runSample(bin, in string, timeout time.Duration) error {
+	expected, err := os.ReadFile(strings.TrimSuffix(in, filepath.Ext(in)) + ".out")
+	if err != nil {
+		return err
+	}
+	input, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = input, &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("time limit exceeded (%s)", timeout)
+		}
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if line := firstDiffLine(trimLines(expected), trimLines(stdout.Bytes())); line > 0 {
+		return fmt.Errorf("wrong answer at line %d", line)
+	}
+	return nil
+}
+
+func trimLines(b []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(b, " \t\r\n"), []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t\r")
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+func stripDir(s, dir string) string {
+	return strings.ReplaceAll(s, dir+string(filepath.Separator), "")
+}
+
+// Failed returns true if any solution failed.
+func (r *CheckReport) Failed() bool {
+	for _, res := range r.Results {
+		if len(res.Errors) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo writes the results.
+func (r *CheckReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	failed := 0
+	for _, res := range r.Results {
+		if len(res.Errors) == 0 {
+			fmt.Fprintf(&b, "ok    %s\n", res.Dir)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(&b, "FAIL  %s\n", res.Dir)
+		for _, err := range res.Errors {
+			fmt.Fprintf(&b, "\t%s\n", strings.ReplaceAll(err, "\n", "\n\t"))
+		}
+	}
+	fmt.Fprintf(&b, "%d solutions, %d failed\n", len(r.Results), failed)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestCheck(t *testing.T) {
+	conf := DefaultConfig()
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+
+	root := testdata.FilePaths.Check
+	r, err := Check(conf, root, CheckOptions{
+		Expected: filepath.Join("testdata", "bundle.go"),
+		Build:    true,
+		Samples:  filepath.Join("testdata", "*.in"),
+		Timeout:  10 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Failed() {
+		t.Error("should be failed")
+	}
+
+	results := make(map[string][]string)
+	for _, res := range r.Results {
+		results[filepath.Base(res.Dir)] = res.Errors
+	}
+
+	if errs, ok := results["ok"]; !ok || len(errs) != 0 {
+		t.Errorf("ok: %v", errs)
+	}
+
+	// the names of libraries collide in the bundle
+	if errs := results["collide"]; len(errs) == 0 || !strings.Contains(errs[0], "Max redeclared") {
+		t.Errorf("collide: %v", errs)
+	}
+
+	want := []string{
+		"differs from bundle.go at line 12",
+		"sample 1.in: wrong answer at line 1",
+	}
+	if errs := results["stale"]; strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stale:\n[want]\n%v\n[actual]\n%v", want, errs)
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "3 solutions, 2 failed\n") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestCheckUpdate(t *testing.T) {
+	conf := DefaultConfig()
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+
+	dir := filepath.Join(testdata.FilePaths.Check, "ok")
+	expected := filepath.Join(t.TempDir(), "bundle.go")
+
+	// the expected file is relative to the solution
+	rel, err := filepath.Rel(dir, expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Check(conf, dir, CheckOptions{Expected: rel, Update: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(dir, "testdata", "bundle.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, actual) {
+		t.Errorf("\n[want]\n%s\n[actual]\n%s", want, actual)
+	}
+}
+
+func TestFirstDiffLine(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{a: "a\nb\n", b: "a\nb\n", want: 0},
+		{a: "a\nb\n", b: "a\nc\n", want: 2},
+		{a: "a\n", b: "a\nb\n", want: 2},
+		{a: "a\nb", b: "a\nb\n", want: 2},
+	}
+	for _, c := range cases {
+		if actual := firstDiffLine([]byte(c.a), []byte(c.b)); actual != c.want {
+			t.Errorf("%q, %q: want %d, actual %d", c.a, c.b, c.want, actual)
+		}
+	}
+}
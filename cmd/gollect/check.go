@@ -0,0 +1,65 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/murosan/gollect"
+)
+
+// check bundles and checks every solution. It exits with 1 if any
+// solution fails.
+//
+//	gollect check -build ./...
+func check(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	cnf := fs.String("config", "", "configuration filepath. the input file and output paths are ignored")
+	expected := fs.String("expected", filepath.Join("testdata", "bundle.go"), "filepath of the expected output relative to each solution. empty disables comparing")
+	update := fs.Bool("update", false, "writes the bundles to the expected files")
+	build := fs.Bool("build", false, "builds the bundles with go command")
+	samples := fs.String("samples", filepath.Join("testdata", "*.in"), "glob of sample inputs relative to each solution. used with -build")
+	timeout := fs.Duration("timeout", 0, "time limit of running each sample")
+	_ = fs.Parse(args)
+
+	roots := fs.Args()
+	if len(roots) == 0 {
+		roots = []string{"./..."}
+	}
+
+	config := loadConfig(*cnf)
+	opts := gollect.CheckOptions{
+		Expected: *expected,
+		Update:   *update,
+		Build:    *build,
+		Samples:  *samples,
+		Timeout:  *timeout,
+	}
+
+	failed := false
+	for _, root := range roots {
+		// directories are always walked recursively
+		root = strings.TrimSuffix(strings.TrimSuffix(root, "..."), "/")
+		if root == "" {
+			root = "."
+		}
+
+		r, err := gollect.Check(config, root, opts)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := r.WriteTo(os.Stdout); err != nil {
+			panic(err)
+		}
+		failed = failed || r.Failed()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/murosan/gollect"
+)
+
+// lint reports constructs of library packages gollect cannot bundle.
+// It exits with 1 if any issue is found.
+//
+//	gollect lint ./...
+func lint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	cnf := fs.String("config", "", "configuration filepath. goos and goarch are used")
+	_ = fs.Parse(args)
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	issues, err := gollect.Lint(loadConfig(*cnf), patterns...)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "%d issues found\n", len(issues))
+		os.Exit(1)
+	}
+}
@@ -6,30 +6,146 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/murosan/gollect"
 )
 
 var (
-	cnf   = flag.String("config", "", "configuration filepath. if specified, all other cli option will be ignored")
-	input = flag.String("in", "main.go", "filepath of main.go or glob for main package files")
-	out   = flag.String("out", "stdout", "output filepath. filepath, 'stdout' and 'clipboard' are available")
-
-	config *gollect.Config
+	cnf     = flag.String("config", "", "configuration filepath. yaml, json and toml are available. the nearest "+gollect.ProjectConfigName+".{yml,yaml,json,toml} is used if empty")
+	input   = flag.String("in", "main.go", "filepath of main.go or glob for main package files")
+	out     = flag.String("out", "stdout", "output filepath. filepath, 'stdout', 'clipboard' and URIs such as file:out/c.go, cmd:pbcopy and osc52: are available")
+	nocache = flag.Bool("nocache", false, "disables caching analyzed library packages")
+	entry   = flag.String("entry-package", "main", "package path of the entry. 'main' means the package of input files")
+	entries = flag.String("entries", "main", "comma separated names of functions or types treated as entry points")
+	snippet = flag.Bool("snippet", false, "omits the package clause from output")
+	snap    = flag.String("snapshot", "", "package path of the library to flatten into one file with all exported declarations")
+	pkgname = flag.String("package", "", "package name of output. the name of the entry package is used if empty")
+	minify  = flag.Bool("minify", false, "drops comments, shortens unexported identifiers of libraries and collapses blank lines")
+	comment = flag.String("comments", "all", "comments left. 'all', 'doc-only', 'none' and 'main-only' are available")
+	banners = flag.Bool("banners", false, "groups declarations of libraries by package and file with banner comments")
+	header  = flag.Bool("header", false, "writes the version, revisions of libraries and the hash of inputs at the head")
+	limit   = flag.Int("size-limit", 0, "maximum bytes of output. 0 means unlimited")
+	report  = flag.Bool("size-report", false, "reports byte contributions of declarations and packages")
 )
 
-func main() {
-	flag.Parse()
+// flagKeys maps the flags to the yaml keys of config.
+// Only the flags set explicitly override the config.
+var flagKeys = map[string]string{
+	"in":            "inputFile",
+	"out":           "outputPaths",
+	"nocache":       "noCache",
+	"entry-package": "entryPackage",
+	"entries":       "entries",
+	"snippet":       "snippet",
+	"snapshot":      "snapshot",
+	"package":       "packageName",
+	"minify":        "minify",
+	"comments":      "comments",
+	"banners":       "banners",
+	"header":        "header",
+	"size-limit":    "sizeLimit",
+	"size-report":   "sizeReport",
+}
 
-	if *cnf == "" {
-		config = gollect.DefaultConfig()
-		config.InputFile = *input
-		config.OutputPaths = []string{*out}
-	} else {
-		config = gollect.LoadConfig(*cnf)
+// subcommands. the first argument selects one of them,
+// otherwise the main package is bundled.
+var commands = map[string]func(args []string){
+	"serve":    serve,
+	"unbundle": unbundle,
+	"stats":    stats,
+	"lint":     lint,
+	"check":    check,
+	"config":   printConfig,
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
 	}
 
+	config := resolveConfig(os.Args[1:]).Config
 	if err := gollect.Main(config); err != nil {
+		// without stack trace, which is left for panics of bugs
+		fmt.Fprintf(os.Stderr, "gollect: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printConfig prints the config resolved with the flags, and where each
+// value came from.
+//
+//	gollect config -out clipboard
+func printConfig(args []string) {
+	if _, err := resolveConfig(args).WriteTo(os.Stdout); err != nil {
 		panic(err)
 	}
 }
+
+// resolveConfig parses the flags, and resolves the config layering the
+// defaults, the user-level config, the project config, environment
+// variables and the flags set, in this order.
+// The project config is searched from the directory of the input file.
+func resolveConfig(args []string) *gollect.ResolvedConfig {
+	_ = flag.CommandLine.Parse(args)
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	dir := "."
+	if set["in"] {
+		dir = filepath.Dir(*input)
+	}
+	layers, err := gollect.DiscoverConfigLayers(*cnf, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		key, ok := flagKeys[f.Name]
+		if !ok {
+			return
+		}
+
+		var v interface{}
+		switch f.Name {
+		case "out":
+			v = []string{*out}
+		case "entries":
+			v = strings.Split(*entries, ",")
+		default:
+			v = f.Value.(flag.Getter).Get()
+		}
+		layers = append(layers, gollect.ConfigLayer{
+			Source: "-" + f.Name,
+			Values: map[string]interface{}{key: v},
+		})
+	})
+
+	r, err := gollect.ResolveConfig(layers...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// loadConfig resolves the config of subcommands, which is the same as
+// resolveConfig without the flags of bundling.
+// The project config is searched from the working directory.
+func loadConfig(path string) *gollect.Config {
+	layers, err := gollect.DiscoverConfigLayers(path, ".")
+	if err != nil {
+		panic(fmt.Errorf("load config: %w", err))
+	}
+	r, err := gollect.ResolveConfig(layers...)
+	if err != nil {
+		panic(fmt.Errorf("load config: %w", err))
+	}
+	return r.Config
+}
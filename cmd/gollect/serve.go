@@ -0,0 +1,36 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/murosan/gollect"
+)
+
+// serve runs gollect as a daemon for editor integrations.
+//
+//	gollect serve -addr unix:/tmp/gollect.sock
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:7317", "address to listen. 'unix:<path>' for Unix domain socket, otherwise host:port")
+	cnf := fs.String("config", "", "configuration filepath used as the base of each request")
+	_ = fs.Parse(args)
+
+	server := gollect.NewServer(loadConfig(*cnf))
+
+	l, err := gollect.Listen(*addr)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "gollect: listening on %s\n", *addr)
+	if err := http.Serve(l, server); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/murosan/gollect"
+)
+
+// stats reports usage of library declarations among solutions.
+//
+//	gollect stats -json -libs github.com/owner/repo/lib/... ./contests
+func stats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "outputs the report as JSON")
+	cnf := fs.String("config", "", "configuration filepath. the input file is ignored")
+	libs := fs.String("libs", "", "comma separated package patterns of the libraries. all packages of the modules of imported libraries are used if empty")
+	_ = fs.Parse(args)
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+
+	var opts gollect.StatsOptions
+	if *libs != "" {
+		opts.Libraries = strings.Split(*libs, ",")
+	}
+
+	config := loadConfig(*cnf)
+	r, err := gollect.Stats(config, root, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	} else {
+		_, err = r.WriteTo(os.Stdout)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	if len(r.Errors) > 0 {
+		os.Exit(1)
+	}
+}
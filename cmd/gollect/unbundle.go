@@ -0,0 +1,39 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/murosan/gollect"
+)
+
+// unbundle rewrites a bundled main.go to import library packages.
+//
+//	gollect unbundle -in old/main.go -lib ./lib -out main.go
+func unbundle(args []string) {
+	fs := flag.NewFlagSet("unbundle", flag.ExitOnError)
+	in := fs.String("in", "main.go", "filepath of bundled main.go")
+	lib := fs.String("lib", "", "root directory of library packages")
+	out := fs.String("out", "stdout", "output filepath. filepath, 'stdout', 'clipboard' and URIs such as file:out/c.go, cmd:pbcopy and osc52: are available")
+	cnf := fs.String("config", "", "configuration filepath. thirdPartyPackagePathPrefixes is used")
+	_ = fs.Parse(args)
+
+	if *lib == "" {
+		fmt.Fprintln(os.Stderr, "gollect unbundle: -lib is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	config := loadConfig(*cnf)
+	config.InputFile = *in
+	config.OutputPaths = []string{*out}
+
+	if err := gollect.Unbundle(config, *lib); err != nil {
+		panic(err)
+	}
+}
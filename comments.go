@@ -0,0 +1,254 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// CommentPolicy decides which comments are left in the output.
+// Compiler directives such as //go:noinline are always left.
+type CommentPolicy string
+
+const (
+	// CommentsAll leaves all comments in the main file, and comments
+	// in the declarations of library packages.
+	CommentsAll CommentPolicy = "all"
+
+	// CommentsDocOnly leaves only doc comments of declarations.
+	CommentsDocOnly CommentPolicy = "doc-only"
+
+	// CommentsNone removes all comments.
+	CommentsNone CommentPolicy = "none"
+
+	// CommentsMainOnly leaves all comments in the main file, and
+	// removes all comments of library packages.
+	CommentsMainOnly CommentPolicy = "main-only"
+)
+
+// ParseCommentPolicy parses the policy. Empty string means CommentsAll.
+func ParseCommentPolicy(s string) (CommentPolicy, error) {
+	switch p := CommentPolicy(s); p {
+	case "":
+		return CommentsAll, nil
+	case CommentsAll, CommentsDocOnly, CommentsNone, CommentsMainOnly:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown comment policy: %s", s)
+}
+
+// forMain returns the policy applied to the main file.
+func (p CommentPolicy) forMain() CommentPolicy {
+	if p == CommentsMainOnly || p == "" {
+		return CommentsAll
+	}
+	return p
+}
+
+// forLibrary returns the policy applied to library packages.
+func (p CommentPolicy) forLibrary() CommentPolicy {
+	switch p {
+	case CommentsMainOnly:
+		return CommentsNone
+	case "":
+		return CommentsAll
+	}
+	return p
+}
+
+// selectComments returns comments in file which belong to the decls
+// and are left by the policy.
+// Comments not in any declaration, such as license headers, are removed.
+func selectComments(file *ast.File, decls []ast.Decl, policy CommentPolicy) []*ast.CommentGroup {
+	var ranges [][2]token.Pos
+	var docs []*ast.CommentGroup
+
+	addDoc := func(doc *ast.CommentGroup) {
+		if doc != nil && len(doc.List) != 0 {
+			docs = append(docs, doc)
+		}
+	}
+	addRange := func(doc *ast.CommentGroup, node ast.Node, comment *ast.CommentGroup) {
+		start, end := node.Pos(), node.End()
+		if doc != nil && len(doc.List) != 0 {
+			start = doc.Pos()
+		}
+		if comment != nil && len(comment.List) != 0 {
+			end = comment.End()
+		}
+		ranges = append(ranges, [2]token.Pos{start, end})
+	}
+
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			addDoc(d.Doc)
+			addRange(d.Doc, d, nil)
+
+		case *ast.GenDecl:
+			addDoc(d.Doc)
+			if d.Lparen.IsValid() {
+				// in the parentheses, only the comments of specs
+				// left are left.
+				if d.Doc != nil && len(d.Doc.List) != 0 {
+					addRange(nil, d.Doc, nil)
+				}
+			} else {
+				addRange(d.Doc, d, nil)
+			}
+
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					addDoc(s.Doc)
+					addRange(s.Doc, s, s.Comment)
+				case *ast.TypeSpec:
+					addDoc(s.Doc)
+					addRange(s.Doc, s, s.Comment)
+				}
+			}
+		}
+	}
+
+	var candidates []*ast.CommentGroup
+	switch policy {
+	case CommentsDocOnly:
+		candidates = docs
+	default:
+		for _, c := range file.Comments {
+			if len(c.List) == 0 {
+				continue
+			}
+			for _, r := range ranges {
+				if r[0] <= c.Pos() && c.End() <= r[1] {
+					candidates = append(candidates, c)
+					break
+				}
+			}
+		}
+	}
+
+	var res []*ast.CommentGroup
+	seen := make(map[*ast.CommentGroup]bool)
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		removeCommentsIf(c, isFileLevel)
+		if policy == CommentsNone {
+			c = directives(c)
+		}
+		if c != nil && len(c.List) != 0 {
+			res = append(res, c)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Pos() < res[j].Pos() })
+	return res
+}
+
+// commentedFile returns a node to print the decls of file with the
+// comments. The package clause is printed at the head.
+func commentedFile(file *ast.File, decls []ast.Decl, comments []*ast.CommentGroup) interface{} {
+	f := &ast.File{
+		Package: file.Package,
+		Name:    file.Name,
+		Decls:   decls,
+
+		// not nil, so that the doc comments of nodes are not printed
+		// instead of the comments. the last one also extends the range
+		// of the node to include the trailing comment.
+		Comments: append([]*ast.CommentGroup{}, comments...),
+	}
+	if len(comments) == 0 {
+		// an empty CommentedNode means to print the comments of nodes
+		return f
+	}
+	return &printer.CommentedNode{Node: f, Comments: comments}
+}
+
+// directives returns new CommentGroup consists of compiler directives
+// in the group. It returns nil if there is no directive.
+func directives(c *ast.CommentGroup) *ast.CommentGroup {
+	if c == nil {
+		return nil
+	}
+
+	var list []*ast.Comment
+	for _, c := range c.List {
+		if isDirective(c) {
+			list = append(list, c)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &ast.CommentGroup{List: list}
+}
+
+// isDirective returns true if the comment is a compiler directive.
+// File-level directives are not directives of declarations.
+func isDirective(c *ast.Comment) bool {
+	return strings.HasPrefix(c.Text, "//go:") && !isFileDirective(c)
+}
+
+// isFileDirective returns true if the comment is a build constraint or
+// a //go:debug directive. They are valid only before the package
+// clause, so they are never left in the bundle.
+func isFileDirective(c *ast.Comment) bool {
+	return constraint.IsGoBuild(c.Text) || constraint.IsPlusBuild(c.Text) ||
+		strings.HasPrefix(c.Text, "//go:debug ")
+}
+
+// isFileLevel returns true if the comment is a file-level directive or
+// a directive of the solution, which are never left in the bundle.
+func isFileLevel(c *ast.Comment) bool {
+	return isFileDirective(c) || isSolutionDirective(c)
+}
+
+// checkDirectives returns an error if the file has directives which
+// cannot be bundled, with the decls left.
+// //go:linkname of the decls depends on the runtime of the judge, and
+// //go:cgo_* directives are only for cgo.
+func checkDirectives(fset *token.FileSet, file *ast.File, decls []ast.Decl) error {
+	names := make(map[string]bool)
+	for _, d := range decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names[d.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if spec, ok := spec.(*ast.ValueSpec); ok {
+					for _, id := range spec.Names {
+						names[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			switch fields := strings.Fields(c.Text); {
+			case strings.HasPrefix(c.Text, "//go:cgo_"):
+				return fmt.Errorf("%s: %s is not supported", fset.Position(c.Pos()), fields[0])
+			case fields[0] == "//go:linkname" && len(fields) >= 2 && names[fields[1]]:
+				return fmt.Errorf("%s: //go:linkname of %s is not supported. implement it with exported API",
+					fset.Position(c.Pos()), fields[1])
+			}
+		}
+	}
+	return nil
+}
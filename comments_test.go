@@ -0,0 +1,147 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestCommentPolicy(t *testing.T) {
+	cases := []struct {
+		policy CommentPolicy
+		want   string
+	}{
+		{
+			policy: CommentsAll,
+			want: `// Package main is a solution.
+package main
+
+// main is the entry.
+func main() {
+	// calls pkg
+	Run() // run
+}
+
+// Run runs.
+//
+//go:noinline
+func Run() {
+	// interior comment
+	x := value // line comment
+	println(x)
+}
+
+// value doc
+const value = 1 // value comment
+`,
+		},
+		{
+			policy: CommentsDocOnly,
+			want: `// Package main is a solution.
+package main
+
+// main is the entry.
+func main() {
+
+	Run()
+}
+
+// Run runs.
+//
+//go:noinline
+func Run() {
+
+	x := value
+	println(x)
+}
+
+// value doc
+const value = 1
+`,
+		},
+		{
+			policy: CommentsNone,
+			want: `package main
+
+func main() {
+
+	Run()
+}
+
+//go:noinline
+func Run() {
+
+	x := value
+	println(x)
+}
+
+const value = 1
+`,
+		},
+		{
+			policy: CommentsMainOnly,
+			want: `// Package main is a solution.
+package main
+
+// main is the entry.
+func main() {
+	// calls pkg
+	Run() // run
+}
+
+//go:noinline
+func Run() {
+
+	x := value
+	println(x)
+}
+
+const value = 1
+`,
+		},
+	}
+
+	for _, c := range cases {
+		program := NewProgram()
+		program.SetWriteOptions(WriteOptions{Comments: c.policy})
+		paths, _ := filepath.Glob(testdata.FilePaths.Comments)
+		ParseAll(program, "main", paths)
+		AnalyzeForeach(program, "main", "main")
+
+		var buf bytes.Buffer
+		if err := Write(&buf, program); err != nil {
+			t.Fatalf("policy: %s, %v", c.policy, err)
+		}
+
+		if actual := buf.String(); actual != c.want {
+			diff := dmp.New().DiffMain(c.want, actual, true)
+			t.Errorf("\n[policy] %s\n[diff]\n%s", c.policy, colorDiff(diff))
+		}
+	}
+}
+
+func TestParseCommentPolicy(t *testing.T) {
+	for s, want := range map[string]CommentPolicy{
+		"":          CommentsAll,
+		"all":       CommentsAll,
+		"doc-only":  CommentsDocOnly,
+		"none":      CommentsNone,
+		"main-only": CommentsMainOnly,
+	} {
+		if p, err := ParseCommentPolicy(s); err != nil || p != want {
+			t.Errorf("in: %s, want: %s, actual: %s, err: %v", s, want, p, err)
+		}
+	}
+
+	if _, err := ParseCommentPolicy("doc"); err == nil {
+		t.Error("should fail")
+	}
+}
@@ -6,12 +6,16 @@ package gollect
 
 import (
 	"errors"
+	"fmt"
+	"go/token"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/atotto/clipboard"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/mod/module"
 )
 
 // Config is a configuration.
@@ -20,12 +24,78 @@ type Config struct {
 	InputFile string `yaml:"inputFile"`
 
 	// list of output paths
-	// filepath, 'stdout' or 'clipboard' are available
+	// filepath, 'stdout', 'clipboard' or URIs of registered schemes,
+	// such as file:out/c.go, cmd:pbcopy and osc52:, are available
 	OutputPaths []string `yaml:"outputPaths"`
 
 	// package path prefixes treat as same as builtin packages.
 	ThirdPartyPackagePathPrefixes []string `yaml:"thirdPartyPackagePathPrefixes"`
 
+	// package path of the entry. "main" means the package of input files.
+	// "main" is used if empty.
+	EntryPackage string `yaml:"entryPackage"`
+
+	// names of functions or types treated as entry points.
+	// all methods of the types are left.
+	// ["main"] is used if empty.
+	Entries []string `yaml:"entries"`
+
+	// omits the package clause from output.
+	// used for judges accepting only functions.
+	Snippet bool `yaml:"snippet"`
+
+	// package path of the library to flatten into one file.
+	// all exported declarations of the package are left.
+	// cannot be used with EntryPackage and Entries.
+	Snapshot string `yaml:"snapshot"`
+
+	// drops comments, shortens unexported identifiers of library
+	// packages and collapses blank lines.
+	Minify bool `yaml:"minify"`
+
+	// decides which comments are left.
+	// one of "all", "doc-only", "none" and "main-only". "all" is used if empty.
+	Comments string `yaml:"comments"`
+
+	// groups declarations of libraries by package and file with
+	// banner comments. cannot be used with Minify.
+	Banners bool `yaml:"banners"`
+
+	// writes the version of gollect, revisions of library modules
+	// and the hash of inputs at the head of output.
+	Header bool `yaml:"header"`
+
+	// package name of output. the name of the entry package is used if empty.
+	PackageName string `yaml:"packageName"`
+
+	// names of functions whose call statements are removed.
+	// e.g, dbg, assert, github.com/owner/repo/lib.Debug
+	StripCalls []string `yaml:"stripCalls"`
+
+	// names of package-level boolean constants. if statements
+	// whose condition is one of them are removed.
+	StripConsts []string `yaml:"stripConsts"`
+
+	// target GOOS and GOARCH used to match build constraints of files.
+	// the values of current environment are used if empty.
+	GOOS   string `yaml:"goos"`
+	GOARCH string `yaml:"goarch"`
+
+	// maximum bytes of output. 0 means unlimited.
+	// e.g, 524288 for AtCoder (512 KiB)
+	SizeLimit int `yaml:"sizeLimit"`
+
+	// reports byte contributions of declarations and packages
+	// even if the size limit is not exceeded.
+	SizeReport bool `yaml:"sizeReport"`
+
+	// directory to cache analyzed library packages.
+	// the directory returned by DefaultCacheDir is used if empty.
+	CacheDir string `yaml:"cacheDir"`
+
+	// disables caching analyzed library packages.
+	NoCache bool `yaml:"noCache"`
+
 	output io.Writer // used by test
 }
 
@@ -42,39 +112,220 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadConfig loads config from yaml file.
-func LoadConfig(path string) *Config {
+// LoadConfig loads config from the file. The format is decided by the
+// extension of the path, one of ConfigExts.
+func LoadConfig(path string) (*Config, error) {
 	if path == "" {
-		return DefaultConfig()
+		return DefaultConfig(), nil
 	}
 
-	bytes, err := os.ReadFile(path)
+	l, err := LoadConfigLayer(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ResolveConfig(l)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return r.Config, nil
+}
 
-	return UnmarshalConfig(bytes)
+// UnmarshalConfig decodes yaml config on DefaultConfig.
+// Unknown keys and values of wrong types are rejected.
+func UnmarshalConfig(b []byte) (*Config, error) {
+	l, err := DecodeConfigLayer("config", b, "yaml")
+	if err != nil {
+		return nil, err
+	}
+	r, err := ResolveConfig(l)
+	if err != nil {
+		return nil, err
+	}
+	return r.Config, nil
 }
 
-func UnmarshalConfig(b []byte) *Config {
-	c := *DefaultConfig()
-	if err := yaml.Unmarshal(b, &c); err != nil {
-		panic(err)
+// PackageCache returns PackageCache configured.
+// It returns nil if caching is disabled or there is no available directory.
+func (c *Config) PackageCache() *PackageCache {
+	if c.NoCache {
+		return nil
 	}
-	return &c
+
+	dir := c.CacheDir
+	if dir == "" {
+		d, err := DefaultCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = d
+	}
+
+	return NewPackageCache(dir)
+}
+
+func (c *Config) entryPackage() string {
+	if c.Snapshot != "" {
+		return c.Snapshot
+	}
+	if c.EntryPackage == "" {
+		return "main"
+	}
+	return c.EntryPackage
+}
+
+func (c *Config) entries() []string {
+	if len(c.Entries) == 0 {
+		return []string{"main"}
+	}
+	return c.Entries
+}
+
+func (c *Config) validateInput() error {
+	if c.InputFile == "" && c.entryPackage() == "main" {
+		return errors.New("input file is empty")
+	}
+	return nil
 }
 
 // Validate validates configuration.
+// It returns all the problems found joined.
 func (c *Config) Validate() error {
-	if c.InputFile == "" {
-		return errors.New("input file is empty")
+	var errs []error
+	add := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	if err := c.validateInput(); err != nil {
+		errs = append(errs, err)
+	} else if _, err := filepath.Match(c.InputFile, ""); err != nil {
+		add("invalid input file %q: %v", c.InputFile, err)
 	}
 
 	for _, out := range c.OutputPaths {
-		if strings.ToLower(out) == "clipboard" && clipboard.Unsupported {
+		if err := validateOutputPath(out); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, prefix := range c.ThirdPartyPackagePathPrefixes {
+		if err := module.CheckImportPath(prefix); err != nil {
+			add("invalid third party package path prefix: %v", err)
+		}
+	}
+
+	if c.EntryPackage != "" && c.EntryPackage != "main" {
+		if err := module.CheckImportPath(c.EntryPackage); err != nil {
+			add("invalid entry package: %v", err)
+		}
+	}
+	for _, e := range c.Entries {
+		if !token.IsIdentifier(e) {
+			add("invalid entry: %q", e)
+		}
+	}
+	if c.Snapshot != "" {
+		if err := module.CheckImportPath(c.Snapshot); err != nil {
+			add("invalid snapshot: %v", err)
+		}
+		if c.EntryPackage != "" && c.EntryPackage != "main" || len(c.Entries) != 0 {
+			add("snapshot cannot be used with entryPackage or entries")
+		}
+	}
+
+	if c.PackageName != "" && !token.IsIdentifier(c.PackageName) {
+		add("invalid package name: %s", c.PackageName)
+	}
+	if _, err := ParseCommentPolicy(c.Comments); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Minify && c.Banners {
+		// banners would be doc comments of the next declarations
+		// after blank lines are collapsed
+		add("banners cannot be used with minify")
+	}
+
+	for _, name := range append(append([]string{}, c.StripCalls...), c.StripConsts...) {
+		if !isQualifiedName(name) {
+			add("invalid name to strip: %q. want a name or a package path followed by a name", name)
+		}
+	}
+
+	if c.GOOS != "" && !goEnvValue.MatchString(c.GOOS) {
+		add("invalid goos: %q", c.GOOS)
+	}
+	if c.GOARCH != "" && !goEnvValue.MatchString(c.GOARCH) {
+		add("invalid goarch: %q", c.GOARCH)
+	}
+
+	if c.SizeLimit < 0 {
+		add("invalid size limit: %d", c.SizeLimit)
+	}
+
+	if c.CacheDir != "" && !c.NoCache {
+		// the cache directory is created on demand
+		if err := checkDir(existingAncestor(c.CacheDir)); err != nil {
+			add("cannot write cache to %s: %v", c.CacheDir, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOutputPath returns an error if the output is invalid, such as
+// a file in a path which is not a directory.
+func validateOutputPath(out string) error {
+	t, err := NewOutputTarget(out)
+	if err != nil {
+		return err
+	}
+
+	switch t := t.(type) {
+	case *clipboardTarget:
+		if clipboard.Unsupported {
 			return errors.New("no clipboard option provided for your operating system")
 		}
+	case *fileTarget:
+		// the directories are created on writing
+		if err := checkDir(existingAncestor(filepath.Dir(t.path))); err != nil {
+			return fmt.Errorf("cannot write output to %s: %w", out, err)
+		}
+	}
+	return nil
+}
+
+var goEnvValue = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// isQualifiedName returns true if s is an identifier, or a package path
+// followed by a dot and an identifier.
+func isQualifiedName(s string) bool {
+	i := strings.LastIndex(s, ".")
+	if i < 0 || strings.LastIndex(s, "/") > i {
+		return token.IsIdentifier(s)
+	}
+	return token.IsIdentifier(s[i+1:]) && module.CheckImportPath(s[:i]) == nil
+}
+
+// existingAncestor returns the nearest existing directory of path,
+// including path itself.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			return path
+		}
+		path = filepath.Dir(path)
 	}
+}
 
+// checkDir returns an error if dir is not a directory.
+// Files are not created to check permissions, which are found on
+// writing.
+func checkDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
 	return nil
 }
@@ -5,8 +5,11 @@
 package gollect
 
 import (
+	"encoding/json"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -18,15 +21,101 @@ func TestLoadConfig(t *testing.T) {
 		OutputPaths:                   []string{"stdout", "clipboard"},
 		ThirdPartyPackagePathPrefixes: DefaultConfig().ThirdPartyPackagePathPrefixes,
 	}
-	actual := LoadConfig(filepath.Join(base, "valid.yml"))
+	for _, name := range []string{"valid.yml", "valid.json", "valid.toml"} {
+		actual, err := LoadConfig(filepath.Join(base, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(want, actual) {
+			t.Errorf("%s\n[want]\n%v\n[actual]\n%v", name, want, actual)
+		}
+	}
+
+	_, err := LoadConfig(filepath.Join(base, "invalid.yml"))
+	if err == nil || !strings.Contains(err.Error(), "invalid.yml:2: outputPaths: want a list of strings") {
+		t.Errorf("want error of type mismatch at line 2, but got %v", err)
+	}
+}
 
-	if !reflect.DeepEqual(want, actual) {
-		t.Errorf("\n[want]\n%v\n[actual]\n%v", want, actual)
+func TestDecodeConfigLayer(t *testing.T) {
+	cases := []struct {
+		format string
+		in     string
+		want   []string // substrings of the error, nil if no error
+	}{
+		{
+			format: "yaml",
+			in:     "inputFile: main.go\noutputPath: [stdout]\nminfy: true\n",
+			want: []string{
+				`x:2: unknown key "outputPath". did you mean "outputPaths"?`,
+				`x:3: unknown key "minfy". did you mean "minify"?`,
+			},
+		},
+		{
+			format: "yaml",
+			in:     "output_paths: [a.go]\nfoo: 1\n",
+			want:   []string{`did you mean "outputPaths"?`, `x:2: unknown key "foo"`},
+		},
+		{
+			format: "yaml",
+			in:     "sizeLimit: big\nminify: 1\n",
+			want:   []string{"x:1: sizeLimit: want an integer", "x:2: minify: want a boolean"},
+		},
+		{
+			format: "yaml",
+			in:     "inputFile: [\n",
+			want:   []string{"x: yaml: line"},
+		},
+		{
+			format: "json",
+			in:     "{\n  \"inputFile\": \"main.go\",\n  \"sizelimit\": 10\n}",
+			want:   []string{`x:3: unknown key "sizelimit". did you mean "sizeLimit"?`},
+		},
+		{
+			format: "json",
+			in:     "{\n  \"sizeLimit\": 1.5\n}",
+			want:   []string{"x:2: sizeLimit: want an integer"},
+		},
+		{
+			format: "json",
+			in:     "{\n  \"inputFile\": \"main.go\",\n}",
+			want:   []string{"x: line 2: invalid character"},
+		},
+		{
+			format: "toml",
+			in:     "inputFile = \"main.go\"\n\nentries = [\"main\", 1]\n",
+			want:   []string{"x:3: entries: want a list of strings"},
+		},
+		{
+			format: "toml",
+			in:     "inputFile = \"main.go\"\nminify = \n",
+			want:   []string{"x: line 2:"},
+		},
+		{
+			format: "toml",
+			in:     "inputFile = \"main.go\"\nsizeLimit = 1024\nstripCalls = [\"dbg\"]\n",
+		},
 	}
 
-	shouldPanic(t, func() {
-		LoadConfig(filepath.Join(base, "invalid.yml"))
-	}, "should fail")
+	for i, c := range cases {
+		_, err := DecodeConfigLayer("x", []byte(c.in), c.format)
+		if c.want == nil {
+			if err != nil {
+				t.Errorf("at:%d want no error, but got %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("at:%d want error, but got nil", i)
+			continue
+		}
+		for _, w := range c.want {
+			if !strings.Contains(err.Error(), w) {
+				t.Errorf("at:%d want error containing %q, but got %v", i, w, err)
+			}
+		}
+	}
 }
 
 func TestUnmarshalConfig(t *testing.T) {
@@ -85,7 +174,11 @@ thirdPartyPackagePathPrefixes: []
 	}
 
 	for i, c := range cases {
-		config := UnmarshalConfig([]byte(c.in))
+		config, err := UnmarshalConfig([]byte(c.in))
+		if err != nil {
+			t.Errorf("at:%d %v", i, err)
+			continue
+		}
 		if !reflect.DeepEqual(config, c.want) {
 			t.Errorf("at:%d\n[want]\n%v\n[actual]\n%v", i, c.want, config)
 		}
@@ -103,4 +196,81 @@ func TestConfig_Validate(t *testing.T) {
 	if err := c2.Validate(); err != nil {
 		t.Errorf("want: nil, actual: %v", err)
 	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		config *Config
+		want   string
+	}{
+		{&Config{InputFile: "[main.go"}, "invalid input file"},
+		{&Config{InputFile: "main.go", OutputPaths: []string{filepath.Join(file, "main.go")}}, "cannot write output"},
+		{&Config{InputFile: "main.go", ThirdPartyPackagePathPrefixes: []string{"golang.org/x/exp/"}}, "invalid third party package path prefix"},
+		{&Config{InputFile: "main.go", Entries: []string{"main", "a b"}}, "invalid entry"},
+		{&Config{InputFile: "main.go", StripCalls: []string{"lib.", "dbg"}}, "invalid name to strip"},
+		{&Config{InputFile: "main.go", GOOS: "Linux"}, "invalid goos"},
+		{&Config{InputFile: "main.go", Snapshot: "lib", Entries: []string{"A"}}, "snapshot cannot be used"},
+		{&Config{InputFile: "main.go", Minify: true, Banners: true}, "banners cannot be used with minify"},
+	}
+	for i, c := range cases {
+		err := c.config.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("at:%d want error containing %q, but got %v", i, c.want, err)
+		}
+	}
+
+	ok := &Config{
+		InputFile:   filepath.Join(dir, "*.go"),
+		OutputPaths: []string{"STDOUT", filepath.Join(dir, "out", "c.go"), "cmd:pbcopy"},
+		StripCalls:  []string{"dbg", "github.com/owner/repo/lib.Debug"},
+		CacheDir:    filepath.Join(dir, "cache", "gollect"),
+	}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("want: nil, actual: %v", err)
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	b, err := os.ReadFile("config.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schema struct {
+		AdditionalProperties *bool `json:"additionalProperties"`
+		Properties           map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if p := schema.AdditionalProperties; p == nil || *p {
+		t.Error("want additionalProperties false")
+	}
+
+	types := map[reflect.Kind]string{
+		reflect.String: "string",
+		reflect.Bool:   "boolean",
+		reflect.Int:    "integer",
+		reflect.Slice:  "array",
+	}
+	typ := reflect.TypeOf(Config{})
+	keys := configKeys()
+	for _, k := range keys {
+		p, ok := schema.Properties[k.key]
+		if !ok {
+			t.Errorf("%s is not in the schema", k.key)
+			continue
+		}
+		if want := types[typ.Field(k.index).Type.Kind()]; p.Type != want {
+			t.Errorf("%s: want type %s, but got %s", k.key, want, p.Type)
+		}
+	}
+	if len(schema.Properties) != len(keys) {
+		t.Errorf("want %d properties, but got %d", len(keys), len(schema.Properties))
+	}
 }
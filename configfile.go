@@ -0,0 +1,348 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigExts are the extensions of config files in the order of
+// priority. The format is decided by the extension.
+var ConfigExts = []string{".yml", ".yaml", ".json", ".toml"}
+
+// configEntry is a top-level entry of a config file.
+type configEntry struct {
+	key   string
+	line  int // 0 if unknown
+	value interface{}
+}
+
+// configFormat returns the format of the config file by its extension.
+// YAML is used for unknown extensions.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return "yaml"
+}
+
+// findConfigFile returns the path of the first existing file named
+// base followed by one of ConfigExts in dir, or empty string.
+func findConfigFile(dir, base string) string {
+	for _, ext := range ConfigExts {
+		path := filepath.Join(dir, base+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadConfigLayer reads the config file as a layer.
+// The format is decided by the extension of the path.
+func LoadConfigLayer(path string) (ConfigLayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ConfigLayer{}, err
+	}
+	return DecodeConfigLayer(path, b, configFormat(path))
+}
+
+// DecodeConfigLayer decodes the config of the format, "yaml", "json" or
+// "toml", as a layer. Unknown keys and values of wrong types are
+// rejected with their line numbers.
+func DecodeConfigLayer(source string, b []byte, format string) (ConfigLayer, error) {
+	var entries []configEntry
+	var err error
+	switch format {
+	case "yaml":
+		entries, err = decodeYAMLConfig(b)
+	case "json":
+		entries, err = decodeJSONConfig(b)
+	case "toml":
+		entries, err = decodeTOMLConfig(b)
+	default:
+		return ConfigLayer{}, fmt.Errorf("%s: unknown config format: %s", source, format)
+	}
+	if err != nil {
+		return ConfigLayer{}, fmt.Errorf("%s: %w", source, err)
+	}
+
+	types := make(map[string]reflect.Type)
+	t := reflect.TypeOf(Config{})
+	for _, k := range configKeys() {
+		types[k.key] = t.Field(k.index).Type
+	}
+
+	l := ConfigLayer{Source: source, Values: make(map[string]interface{})}
+	seen := make(map[string]bool)
+	var errs []error
+	report := func(e configEntry, format string, a ...interface{}) {
+		pos := source
+		if e.line > 0 {
+			pos = fmt.Sprintf("%s:%d", source, e.line)
+		}
+		errs = append(errs, fmt.Errorf("%s: %s", pos, fmt.Sprintf(format, a...)))
+	}
+
+	for _, e := range entries {
+		t, ok := types[e.key]
+		switch {
+		case !ok:
+			if s := suggestConfigKey(e.key); s != "" {
+				report(e, "unknown key %q. did you mean %q?", e.key, s)
+			} else {
+				report(e, "unknown key %q", e.key)
+			}
+		case seen[e.key]:
+			report(e, "duplicated key %q", e.key)
+		default:
+			v, err := normalizeConfigValue(t, e.value)
+			if err != nil {
+				report(e, "%s: %v", e.key, err)
+				continue
+			}
+			l.Values[e.key] = v
+		}
+		seen[e.key] = true
+	}
+	return l, errors.Join(errs...)
+}
+
+func decodeYAMLConfig(b []byte) ([]configEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		// empty
+		return nil, nil
+	}
+
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: config must be a mapping", m.Line)
+	}
+
+	var entries []configEntry
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		e := configEntry{key: k.Value, line: k.Line}
+		if err := v.Decode(&e.value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func decodeJSONConfig(b []byte) ([]configEntry, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	wrap := func(err error) error {
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			return fmt.Errorf("line %d: %w", lineAt(b, int(serr.Offset)), err)
+		}
+		return err
+	}
+
+	tok, err := dec.Token()
+	if err == io.EOF {
+		// empty
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrap(err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, fmt.Errorf("line %d: config must be an object", lineAt(b, int(dec.InputOffset())))
+	}
+
+	var entries []configEntry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, wrap(err)
+		}
+		e := configEntry{key: tok.(string), line: lineAt(b, int(dec.InputOffset()))}
+		if err := dec.Decode(&e.value); err != nil {
+			return nil, wrap(err)
+		}
+		entries = append(entries, e)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, wrap(err)
+	}
+	return entries, nil
+}
+
+func decodeTOMLConfig(b []byte) ([]configEntry, error) {
+	var m map[string]interface{}
+	if _, err := toml.Decode(string(b), &m); err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return nil, fmt.Errorf("line %d: %s", perr.Position.Line, perr.Message)
+		}
+		return nil, err
+	}
+
+	// the decoder does not report positions of keys, so they are
+	// searched from the source. the config has only top-level keys.
+	var entries []configEntry
+	for k, v := range m {
+		line := 0
+		re := regexp.MustCompile(`(?m)^[ \t]*(\[[ \t]*)?("?)` + regexp.QuoteMeta(k) + `("?)[ \t]*[=\]]`)
+		if loc := re.FindIndex(b); loc != nil {
+			line = lineAt(b, loc[0])
+		}
+		entries = append(entries, configEntry{key: k, line: line, value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].line != entries[j].line {
+			return entries[i].line < entries[j].line
+		}
+		return entries[i].key < entries[j].key
+	})
+	return entries, nil
+}
+
+func lineAt(b []byte, offset int) int {
+	if offset > len(b) {
+		offset = len(b)
+	}
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
+
+// normalizeConfigValue converts the decoded value to the type of the
+// field, so that values of every format are merged in the same way.
+// null is the zero value.
+func normalizeConfigValue(t reflect.Type, v interface{}) (interface{}, error) {
+	if v == nil {
+		return reflect.Zero(t).Interface(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case reflect.Bool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case reflect.Int:
+		switch n := v.(type) {
+		case int:
+			return n, nil
+		case int64:
+			return int(n), nil
+		case uint64:
+			if n <= math.MaxInt {
+				return int(n), nil
+			}
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case reflect.Slice:
+		list, ok := v.([]interface{})
+		if !ok {
+			break
+		}
+		a := make([]string, len(list))
+		for i, e := range list {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("want a list of strings, but got %s in the list", describeValue(e))
+			}
+			a[i] = s
+		}
+		return a, nil
+	}
+
+	want := map[reflect.Kind]string{
+		reflect.String: "a string",
+		reflect.Bool:   "a boolean",
+		reflect.Int:    "an integer",
+		reflect.Slice:  "a list of strings",
+	}[t.Kind()]
+	return nil, fmt.Errorf("want %s, but got %s", want, describeValue(v))
+}
+
+func describeValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case int, int64, uint64, float64:
+		return fmt.Sprintf("number %v", v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a mapping"
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// suggestConfigKey returns the known key closest to the unknown key.
+// It returns empty string if no key is close enough.
+func suggestConfigKey(key string) string {
+	norm := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+	}
+
+	best, dist := "", 4 // at most 3 edits
+	for _, k := range configKeys() {
+		if norm(k.key) == norm(key) {
+			return k.key
+		}
+		if d := levenshtein(norm(key), norm(k.key)); d < dist {
+			best, dist = k.key, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -7,6 +7,8 @@ package gollect
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"sort"
 	"strings"
 )
 
@@ -37,6 +39,8 @@ type Decl interface {
 	Use()
 	Uses(Decl)
 	GetUses() DeclSet
+	IsKept() bool
+	IsExcluded() bool
 	fmt.Stringer
 }
 
@@ -81,6 +85,8 @@ type CommonDecl struct {
 	uses DeclSet
 	isinit,
 	isunderscore bool
+	kept,
+	excluded bool
 }
 
 // NewCommonDecl returns new CommonDecl.
@@ -120,6 +126,18 @@ func (d *CommonDecl) GetUses() DeclSet { return d.uses }
 // Use change this and its dependencies' used field to true.
 func (d *CommonDecl) Use() { d.used = true }
 
+// Keep makes the decl left even if it is not used from main.
+func (d *CommonDecl) Keep() { d.kept = true }
+
+// IsKept returns true if the decl should be left even if it is not used.
+func (d *CommonDecl) IsKept() bool { return d.kept }
+
+// Exclude makes the decl not allowed to be used from main.
+func (d *CommonDecl) Exclude() { d.excluded = true }
+
+// IsExcluded returns true if the decl is not allowed to be used.
+func (d *CommonDecl) IsExcluded() bool { return d.excluded }
+
 // IsInitOrUnderScore return true if the Desc is init func or
 // var declared with underscore.
 func (d *CommonDecl) IsInitOrUnderScore() bool { return d.isinit || d.isunderscore }
@@ -129,9 +147,11 @@ func (d *CommonDecl) String() string { return declToString(d) }
 // TypeDecl represents Type declaration.
 type TypeDecl struct {
 	*CommonDecl
+	alias   AliasKind
 	methods struct {
-		mset map[string]*MethodDecl
-		keep bool
+		mset  map[string]*MethodDecl
+		keep  bool
+		names map[string]bool
 	}
 }
 
@@ -141,11 +161,13 @@ func NewTypeDecl(pkg *Package, ids ...string) *TypeDecl {
 	return &TypeDecl{
 		CommonDecl: NewCommonDecl(pkg, ids...),
 		methods: struct {
-			mset map[string]*MethodDecl
-			keep bool
+			mset  map[string]*MethodDecl
+			keep  bool
+			names map[string]bool
 		}{
-			mset: make(map[string]*MethodDecl),
-			keep: false,
+			mset:  make(map[string]*MethodDecl),
+			keep:  false,
+			names: make(map[string]bool),
 		},
 	}
 }
@@ -183,10 +205,59 @@ func (d *TypeDecl) GetMethodByName(name string) (*MethodDecl, bool) {
 // KeepMethod set true its keep method option.
 // When the field is true, all methods will not removed even the method
 // is not used from main.
-func (d *TypeDecl) KeepMethod() { d.methods.keep = true }
+// If names are given, only the methods of the names will not be removed.
+func (d *TypeDecl) KeepMethod(names ...string) {
+	if len(names) == 0 {
+		d.methods.keep = true
+	}
+	for _, name := range names {
+		d.methods.names[name] = true
+	}
+}
 
+// ShouldKeepMethods returns true if all methods should be left.
 func (d *TypeDecl) ShouldKeepMethods() bool { return d.methods.keep }
 
+// ShouldKeepMethod returns true if the method of the name should be left.
+func (d *TypeDecl) ShouldKeepMethod(name string) bool {
+	return d.methods.keep || d.methods.names[name]
+}
+
+// KeptMethodNames returns names of methods should be left.
+// The names are not included if all methods should be left.
+func (d *TypeDecl) KeptMethodNames() []string { return sortedKeys(d.methods.names) }
+
+// AliasKind is a kind of type alias declarations.
+type AliasKind int
+
+const (
+	// NotAlias is a defined type.
+	NotAlias AliasKind = iota
+
+	// Alias is a type alias.
+	Alias
+
+	// SameNameAlias is a type alias to the type of the same name in
+	// other bundled package with the same type parameters, such as
+	// `type Graph = lib.Graph`. The alias is removed from the output,
+	// because they are identical after bundling.
+	SameNameAlias
+
+	// ConflictingAlias is a type alias to the type of the same name in
+	// other bundled package with different type arguments, such as
+	// `type Graph = lib.Graph[int]`. It cannot be bundled.
+	ConflictingAlias
+)
+
+// SetAlias sets the kind of alias.
+func (d *TypeDecl) SetAlias(k AliasKind) { d.alias = k }
+
+// Alias returns the kind of alias.
+func (d *TypeDecl) Alias() AliasKind { return d.alias }
+
+// IsAlias returns true if the type is declared as an alias.
+func (d *TypeDecl) IsAlias() bool { return d.alias != NotAlias }
+
 // MethodDecl represents method declaration.
 type MethodDecl struct {
 	*CommonDecl
@@ -221,6 +292,12 @@ func (d *MethodDecl) IsEmbedded() bool { return d.embedded }
 // SetEmbedded change its embedded field to true.
 func (d *MethodDecl) SetEmbedded(b bool) { d.embedded = b }
 
+// declName returns human readable name of the decl.
+// e.g, github.com/owner/repo/lib.Type.Method
+func declName(decl Decl) string {
+	return decl.Pkg().Path() + "." + strings.Join(declKeys(decl), ".")
+}
+
 func declToString(decl Decl) string {
 	var tpe string
 	switch decl.(type) {
@@ -307,6 +384,36 @@ func (s DeclSet) ListInitOrUnderscore() (a []Decl) {
 	return
 }
 
+// ListKept returns Decl list annotated to be left even if
+// they are not used from main.
+func (s DeclSet) ListKept() (a []Decl) {
+	for _, v := range s {
+		if v.IsKept() {
+			a = append(a, v)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].ID() < a[j].ID() })
+	return
+}
+
+// ListExported returns Decl list of exported functions, variables,
+// constants and types of the package. Methods are not included.
+func (s DeclSet) ListExported(pkg *Package) (a []Decl) {
+	for _, v := range s {
+		if v.Pkg() != pkg {
+			continue
+		}
+		if _, ok := v.(*MethodDecl); ok {
+			continue
+		}
+		if keys := declKeys(v); token.IsExported(keys[len(keys)-1]) {
+			a = append(a, v)
+		}
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i].ID() < a[j].ID() })
+	return
+}
+
 func (s DeclSet) String() string {
 	var v []string
 	for _, d := range s {
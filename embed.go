@@ -0,0 +1,173 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const embedDirective = "//go:embed"
+
+// embedKind is a type of variables with //go:embed directive.
+type embedKind int
+
+const (
+	embedUnsupported embedKind = iota
+	embedString
+	embedBytes
+	embedFS
+)
+
+// inlineEmbeds replaces the variables with //go:embed directives in
+// the decls with the literals of the embedded files, since the files
+// are not submitted. The directives are removed.
+// Only string and []byte are supported, because embed.FS needs an
+// implementation of io/fs.
+func inlineEmbeds(fset *token.FileSet, decls []ast.Decl) error {
+	for _, decl := range decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR {
+			continue
+		}
+
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+
+			docs := embedDocs(gd, vs)
+			patterns := embedPatterns(docs...)
+			if len(patterns) == 0 {
+				continue
+			}
+
+			if err := inlineEmbed(fset, vs, patterns); err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				removeCommentsIf(doc, isEmbedDirective)
+			}
+		}
+	}
+	return nil
+}
+
+func inlineEmbed(fset *token.FileSet, spec *ast.ValueSpec, patterns []string) error {
+	pos := fset.Position(spec.Pos())
+	name := spec.Names[0].Name
+
+	kind := embedKindOf(spec.Type)
+	switch kind {
+	case embedFS:
+		return fmt.Errorf("%s: embed.FS %s cannot be bundled. embed the files as string or []byte", pos, name)
+	case embedUnsupported:
+		return fmt.Errorf("%s: unsupported type of //go:embed variable %s", pos, name)
+	}
+
+	dir := filepath.Dir(pos.Filename)
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern %s: %w", pos, pattern, err)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) != 1 {
+		return fmt.Errorf("%s: //go:embed of %s must match exactly one file, but matched %d", pos, name, len(paths))
+	}
+
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", pos, err)
+	}
+
+	// placed at the position of the type, so that the comments are
+	// printed at the same positions.
+	var value ast.Expr = &ast.BasicLit{ValuePos: spec.Type.Pos(), Kind: token.STRING, Value: stringLiteral(string(b))}
+	if kind == embedBytes {
+		value = &ast.CallExpr{Fun: spec.Type, Args: []ast.Expr{value}}
+	}
+
+	spec.Type = nil
+	spec.Values = []ast.Expr{value}
+	return nil
+}
+
+// embedDocs returns the comment groups which may have //go:embed
+// directives of the spec. The doc of the decl is included only if the
+// decl has no other specs, as same as go command.
+func embedDocs(gd *ast.GenDecl, spec *ast.ValueSpec) []*ast.CommentGroup {
+	docs := []*ast.CommentGroup{spec.Doc}
+	if len(gd.Specs) == 1 {
+		docs = append(docs, gd.Doc)
+	}
+	return docs
+}
+
+// embedPatterns returns the patterns of //go:embed directives.
+func embedPatterns(docs ...*ast.CommentGroup) (patterns []string) {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			if !isEmbedDirective(c) {
+				continue
+			}
+			for _, f := range strings.Fields(strings.TrimPrefix(c.Text, embedDirective)) {
+				if p, err := strconv.Unquote(f); err == nil {
+					f = p
+				}
+				patterns = append(patterns, f)
+			}
+		}
+	}
+	return
+}
+
+func isEmbedDirective(c *ast.Comment) bool {
+	return c.Text == embedDirective || strings.HasPrefix(c.Text, embedDirective+" ")
+}
+
+// embedKindOf returns the kind of the type expression.
+// The types are decided syntactically, because cached packages are
+// not type-checked.
+func embedKindOf(expr ast.Expr) embedKind {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if expr.Name == "string" {
+			return embedString
+		}
+	case *ast.ArrayType:
+		if id, ok := expr.Elt.(*ast.Ident); ok && expr.Len == nil && id.Name == "byte" {
+			return embedBytes
+		}
+	case *ast.SelectorExpr:
+		if x, ok := expr.X.(*ast.Ident); ok && x.Name == "embed" && expr.Sel.Name == "FS" {
+			return embedFS
+		}
+	}
+	return embedUnsupported
+}
+
+// stringLiteral returns the raw string literal of s if possible,
+// so that texts are readable. Otherwise, the quoted one.
+func stringLiteral(s string) string {
+	if !utf8.ValidString(s) || strings.ContainsAny(s, "`\r\uFEFF") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < ' ' && r != '\t' && r != '\n' || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return "`" + s + "`"
+}
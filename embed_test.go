@@ -0,0 +1,65 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInlineEmbedsError(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  "import \"embed\"\n\n//go:embed *.txt\nvar fs embed.FS",
+			want: "embed.FS fs cannot be bundled",
+		},
+		{
+			src:  "//go:embed a.txt\nvar n int",
+			want: "unsupported type of //go:embed variable n",
+		},
+		{
+			src:  "//go:embed missing.txt\nvar s string",
+			want: "must match exactly one file, but matched 0",
+		},
+	}
+
+	for i, c := range cases {
+		fset := token.NewFileSet()
+		path := filepath.Join(t.TempDir(), "a.go")
+		file, err := parser.ParseFile(fset, path, "package a\n\n"+c.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = inlineEmbeds(fset, file.Decls)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("At: %d, want error containing %q, but got %v", i, c.want, err)
+		}
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "abc\n\tdef\n", want: "`abc\n\tdef\n`"},
+		{in: "a`b", want: "\"a`b\""},
+		{in: "a\r\n", want: `"a\r\n"`},
+		{in: "\x00\xff", want: `"\x00\xff"`},
+	}
+
+	for i, c := range cases {
+		if actual := stringLiteral(c.in); actual != c.want {
+			t.Errorf("At: %d, want %s, but got %s", i, c.want, actual)
+		}
+	}
+}
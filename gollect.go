@@ -6,6 +6,7 @@ package gollect
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 )
 
@@ -16,29 +17,114 @@ func Main(config *Config) error {
 	}
 
 	setThirdPartyPackagePathPrefixes(config.ThirdPartyPackagePathPrefixes)
+	setBuildContext(config.GOOS, config.GOARCH)
 
+	p := newProgram(config)
+
+	w := &writer{config: config}
+
+	if err := bundle(p, config, w); err != nil {
+		return err
+	}
+	if err := checkSize(p, config, w.buf.Len()); err != nil {
+		return err
+	}
+	if err := w.writeForeach(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newProgram returns new Program configured.
+func newProgram(config *Config) *Program {
 	p := NewProgram()
+	p.SetPackageCache(config.PackageCache())
+	p.SetStripper(NewStripper(config.StripCalls, config.StripConsts))
+	p.SetEntryPackage(config.entryPackage())
+	p.SetWriteOptions(WriteOptions{
+		Snippet:     config.Snippet,
+		PackageName: config.PackageName,
+		Minify:      config.Minify,
+		Comments:    CommentPolicy(config.Comments),
+		Banners:     config.Banners,
+		Header:      config.Header,
+	})
+	return p
+}
+
+// checkSize reports the size of output if the limit is exceeded or
+// the report is requested. It returns an error if the limit is exceeded.
+func checkSize(p *Program, config *Config, size int) error {
+	exceeded := config.SizeLimit > 0 && size > config.SizeLimit
+	if !exceeded && !config.SizeReport {
+		return nil
+	}
+
+	r := NewSizeReport(p, size, config.SizeLimit)
+
+	if _, err := r.WriteTo(WarnOutput); err != nil {
+		return err
+	}
+	if r.Exceeded() {
+		return fmt.Errorf("output size %d bytes exceeds the limit %d bytes", r.Total, r.Limit)
+	}
+	return nil
+}
+
+// bundle parses and analyzes input files, then writes the result to w.
+func bundle(p *Program, config *Config, w io.Writer) error {
+	if err := parse(p, config); err != nil {
+		return err
+	}
+	if err := analyze(p, config); err != nil {
+		return err
+	}
+	if err := precomputeProgram(p, config); err != nil {
+		return err
+	}
+	return Write(w, p)
+}
+
+// parse parses ast files of the entry package and its dependencies.
+// Directives in the files of the main package are merged on the config.
+func parse(p *Program, config *Config) error {
+	entry := p.EntryPackage()
+	if entry != "main" {
+		ParseAll(p, entry, p.PackageCache().FilePaths(entry))
+		return nil
+	}
 
 	paths, err := filepath.Glob(config.InputFile)
 	if err != nil {
 		return fmt.Errorf("parse glob: %w", err)
 	}
 
-	// parse ast files and check dependencies
-	ParseAll(p, "main", paths)
-	AnalyzeForeach(p, "main", "main")
-
-	w := &writer{
-		config:   config,
-		provider: &writerProviderImpl{},
+	paths, err = MatchBuildContext(paths)
+	if err != nil {
+		return fmt.Errorf("match build context: %w", err)
 	}
 
-	if err := Write(w, p); err != nil {
+	// directives of the solution are applied before parsing the
+	// dependencies, since they may change the packages treated as builtin.
+	pkg := ParsePackage(p, entry, paths)
+	d, err := parseSolutionDirectives(p.FileSet(), pkg.files)
+	if err != nil {
 		return err
 	}
-	if err := w.writeForeach(); err != nil {
-		return err
+	if d.apply(config) {
+		setThirdPartyPackagePathPrefixes(config.ThirdPartyPackagePathPrefixes)
 	}
 
+	ParseDependencies(p, pkg)
 	return nil
 }
+
+// analyze checks dependencies from the entries.
+func analyze(p *Program, config *Config) error {
+	if config.Snapshot != "" {
+		AnalyzeSnapshot(p, p.EntryPackage())
+		return nil
+	}
+	return AnalyzeForeach(p, p.EntryPackage(), config.entries()...)
+}
@@ -24,12 +24,8 @@ func TestGollect(t *testing.T) {
 	for i, tc := range testdata.Cases {
 		var buf bytes.Buffer
 
-		conf := &Config{
-			InputFile:                     tc.Input,
-			OutputPaths:                   nil,
-			ThirdPartyPackagePathPrefixes: []string{"golang.org/x/exp"},
-			output:                        &buf,
-		}
+		conf := caseConfig(t, tc)
+		conf.output = &buf
 
 		fatal := func(t *testing.T, i int, msg string, err error) {
 			t.Helper()
@@ -6,6 +6,8 @@ package gollect
 
 import (
 	"testing"
+
+	"github.com/murosan/gollect/testdata"
 )
 
 func shouldPanic(t *testing.T, f func(), onfail string) {
@@ -19,3 +21,22 @@ func shouldPanic(t *testing.T, f func(), onfail string) {
 
 	f()
 }
+
+// caseConfig returns configuration for the test case.
+func caseConfig(t *testing.T, tc testdata.Case) *Config {
+	t.Helper()
+	conf := &Config{}
+	if tc.Config != "" {
+		c, err := LoadConfig(tc.Config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf = c
+	}
+
+	conf.InputFile = tc.Input
+	conf.OutputPaths = nil
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+	return conf
+}
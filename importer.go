@@ -0,0 +1,115 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/gcexportdata"
+)
+
+// packageImporter imports the packages of the program for type checking.
+// Parsed packages are type-checked from source, or read from the export
+// data restored from PackageCache. The others such as standard packages
+// are imported by the importer of the program.
+type packageImporter struct {
+	program  *Program
+	fset     *token.FileSet            // positions of export data
+	imported map[string]*types.Package // shared by export data
+	checking map[string]bool
+}
+
+func newPackageImporter(program *Program) *packageImporter {
+	return &packageImporter{
+		program:  program,
+		fset:     token.NewFileSet(),
+		imported: make(map[string]*types.Package),
+		checking: make(map[string]bool),
+	}
+}
+
+// Import implements types.Importer.
+func (i *packageImporter) Import(path string) (*types.Package, error) {
+	return i.ImportFrom(path, "", 0)
+}
+
+// ImportFrom implements types.ImporterFrom.
+func (i *packageImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	pkg, ok := i.program.PackageSet().Get(path)
+	if !ok || isBuiltinPackage(path) {
+		imp := i.program.Importer()
+
+		var tpkg *types.Package
+		var err error
+		if from, ok := imp.(types.ImporterFrom); ok {
+			tpkg, err = from.ImportFrom(path, dir, mode)
+		} else {
+			tpkg, err = imp.Import(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		i.add(tpkg)
+		return tpkg, nil
+	}
+
+	if err := i.check(pkg); err != nil {
+		return nil, err
+	}
+	return pkg.types, nil
+}
+
+// check type-checks the package, or reads its export data if it is
+// restored from cache. Type errors of source are panics as ExecCheck.
+func (i *packageImporter) check(pkg *Package) error {
+	if pkg.types != nil {
+		return nil
+	}
+	if i.checking[pkg.path] {
+		return fmt.Errorf("import cycle not allowed: %s", pkg.path)
+	}
+	i.checking[pkg.path] = true
+
+	if pkg.cached && len(pkg.export) > 0 {
+		// the packages the export data refers to must be imported
+		// first, so that their types are identical to the others'.
+		for _, file := range pkg.files {
+			for _, spec := range file.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				if _, err := i.Import(path); err != nil {
+					return err
+				}
+			}
+		}
+
+		tpkg, err := gcexportdata.Read(bytes.NewReader(pkg.export), i.fset, i.imported, pkg.path)
+		if err == nil {
+			pkg.types = tpkg
+			i.add(tpkg)
+			return nil
+		}
+		// falls back to source
+	}
+
+	ExecCheck(i.program.FileSet(), i, pkg)
+	i.add(pkg.types)
+	return nil
+}
+
+// add registers the package and its imports for reading export data.
+func (i *packageImporter) add(pkg *types.Package) {
+	if _, ok := i.imported[pkg.Path()]; ok {
+		return
+	}
+	i.imported[pkg.Path()] = pkg
+	for _, imp := range pkg.Imports() {
+		i.add(imp)
+	}
+}
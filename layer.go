@@ -0,0 +1,269 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ProjectConfigName is the name of the config file searched from
+	// the input directory to the root, followed by one of ConfigExts.
+	ProjectConfigName = ".gollect"
+
+	// EnvPrefix is the prefix of environment variables of config.
+	// e.g, GOLLECT_OUTPUT_PATHS for outputPaths.
+	EnvPrefix = "GOLLECT_"
+
+	sourceDefault = "default"
+)
+
+// ConfigLayer is a set of config values keyed by the yaml keys, with
+// the source they came from.
+type ConfigLayer struct {
+	Source string
+	Values map[string]interface{}
+}
+
+// ResolvedConfig is the config merged from layers.
+type ResolvedConfig struct {
+	Config *Config
+
+	// sources of the values by the yaml keys.
+	// "default" if the value is not set by any layer.
+	Sources map[string]string
+}
+
+// configKey is a field of Config with its yaml key.
+type configKey struct {
+	key   string
+	index int
+}
+
+// configKeys returns the keys of Config in the order of fields.
+func configKeys() (keys []configKey) {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("yaml"); key != "" {
+			keys = append(keys, configKey{key: key, index: i})
+		}
+	}
+	return
+}
+
+// UserConfigPath returns the path of the user-level config,
+// $XDG_CONFIG_HOME/gollect/config followed by one of ConfigExts.
+// $HOME/.config is used if XDG_CONFIG_HOME is not set, and config.yml
+// is returned if there is no such file.
+func UserConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	dir = filepath.Join(dir, "gollect")
+	if path := findConfigFile(dir, "config"); path != "" {
+		return path, nil
+	}
+	return filepath.Join(dir, "config.yml"), nil
+}
+
+// FindProjectConfig returns the path of the nearest ProjectConfigName
+// walking up from dir. It returns empty string if there is no such file.
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if path := findConfigFile(dir, ProjectConfigName); path != "" {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// DiscoverConfigLayers returns the layers of the user-level config,
+// the project config and environment variables in this order.
+// The project config is the file at path if it is not empty, otherwise
+// the nearest ProjectConfigName walking up from dir.
+// Config files not found are skipped, except for the one at path.
+func DiscoverConfigLayers(path, dir string) ([]ConfigLayer, error) {
+	var layers []ConfigLayer
+
+	if user, err := UserConfigPath(); err == nil {
+		l, err := LoadConfigLayer(user)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil {
+			layers = append(layers, l)
+		}
+	}
+
+	if path == "" {
+		path = FindProjectConfig(dir)
+	}
+	if path != "" {
+		l, err := LoadConfigLayer(path)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+
+	env, err := EnvConfigLayers(os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	return append(layers, env...), nil
+}
+
+// EnvConfigLayers returns a layer for each environment variable of
+// config set. The name of the variable is EnvPrefix followed by the
+// yaml key in upper snake case. Lists are separated by commas.
+func EnvConfigLayers(lookup func(string) (string, bool)) ([]ConfigLayer, error) {
+	t := reflect.TypeOf(Config{})
+
+	var layers []ConfigLayer
+	for _, k := range configKeys() {
+		name := EnvPrefix + upperSnake(k.key)
+		s, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		var v interface{}
+		switch kind := t.Field(k.index).Type.Kind(); kind {
+		case reflect.String:
+			v = s
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("$%s: %w", name, err)
+			}
+			v = b
+		case reflect.Int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("$%s: %w", name, err)
+			}
+			v = n
+		case reflect.Slice:
+			list := []string{}
+			for _, e := range strings.Split(s, ",") {
+				if e = strings.TrimSpace(e); e != "" {
+					list = append(list, e)
+				}
+			}
+			v = list
+		default:
+			panic(fmt.Sprintf("unsupported kind of config: %s", kind))
+		}
+
+		layers = append(layers, ConfigLayer{
+			Source: "$" + name,
+			Values: map[string]interface{}{k.key: v},
+		})
+	}
+	return layers, nil
+}
+
+// upperSnake converts camel case to upper snake case.
+// e.g, outputPaths → OUTPUT_PATHS
+func upperSnake(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// ResolveConfig merges the layers on DefaultConfig in order, so that
+// the values of later layers take precedence.
+func ResolveConfig(layers ...ConfigLayer) (*ResolvedConfig, error) {
+	r := &ResolvedConfig{Config: DefaultConfig(), Sources: make(map[string]string)}
+	for _, k := range configKeys() {
+		r.Sources[k.key] = sourceDefault
+	}
+
+	for _, l := range layers {
+		if len(l.Values) == 0 {
+			continue
+		}
+
+		b, err := yaml.Marshal(l.Values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Source, err)
+		}
+		if err := yaml.Unmarshal(b, r.Config); err != nil {
+			return nil, fmt.Errorf("%s: %w", l.Source, err)
+		}
+		for key := range l.Values {
+			r.Sources[key] = l.Source
+		}
+	}
+	return r, nil
+}
+
+// WriteTo writes the config as yaml, with the source of each value
+// as a comment.
+func (r *ResolvedConfig) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	v := reflect.ValueOf(r.Config).Elem()
+	for _, k := range configKeys() {
+		value, err := flowYAML(v.Field(k.index).Interface())
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(&b, "%s: %s # %s\n", k.key, value, r.Sources[k.key])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// flowYAML returns the value in yaml flow style.
+func flowYAML(v interface{}) (string, error) {
+	if list, ok := v.([]string); ok {
+		elems := make([]string, len(list))
+		for i, e := range list {
+			s, err := flowYAML(e)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = s
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	}
+
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
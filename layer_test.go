@@ -0,0 +1,166 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveConfig(t *testing.T) {
+	r, err := ResolveConfig(
+		ConfigLayer{Source: "user", Values: map[string]interface{}{
+			"sizeLimit":   100,
+			"outputPaths": []interface{}{"a.go", "b.go"},
+		}},
+		ConfigLayer{Source: "project", Values: map[string]interface{}{
+			"outputPaths": []interface{}{"c.go"},
+			"minify":      true,
+		}},
+		ConfigLayer{Source: "-size-limit", Values: map[string]interface{}{"sizeLimit": 0}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultConfig()
+	want.OutputPaths = []string{"c.go"}
+	want.Minify = true
+	if !reflect.DeepEqual(r.Config, want) {
+		t.Errorf("\n[want]\n%v\n[actual]\n%v", want, r.Config)
+	}
+
+	sources := map[string]string{
+		"inputFile":   "default",
+		"outputPaths": "project",
+		"minify":      "project",
+		"sizeLimit":   "-size-limit",
+	}
+	for key, want := range sources {
+		if actual := r.Sources[key]; actual != want {
+			t.Errorf("source of %s: want %s, but got %s", key, want, actual)
+		}
+	}
+
+	_, err = ResolveConfig(ConfigLayer{Source: "invalid", Values: map[string]interface{}{"minify": "yes please"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid: ") {
+		t.Errorf("want error of the source, but got %v", err)
+	}
+}
+
+func TestEnvConfigLayers(t *testing.T) {
+	env := map[string]string{
+		"GOLLECT_OUTPUT_PATHS": "out/a.go, clipboard",
+		"GOLLECT_NO_CACHE":     "true",
+		"GOLLECT_SIZE_LIMIT":   "524288",
+		"GOLLECT_GOOS":         "linux",
+	}
+	layers, err := EnvConfigLayers(func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ResolveConfig(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultConfig()
+	want.OutputPaths = []string{"out/a.go", "clipboard"}
+	want.NoCache = true
+	want.SizeLimit = 524288
+	want.GOOS = "linux"
+	if !reflect.DeepEqual(r.Config, want) {
+		t.Errorf("\n[want]\n%v\n[actual]\n%v", want, r.Config)
+	}
+	if s := r.Sources["outputPaths"]; s != "$GOLLECT_OUTPUT_PATHS" {
+		t.Errorf("want source $GOLLECT_OUTPUT_PATHS, but got %s", s)
+	}
+
+	_, err = EnvConfigLayers(func(k string) (string, bool) { return "x", k == "GOLLECT_MINIFY" })
+	if err == nil || !strings.Contains(err.Error(), "$GOLLECT_MINIFY") {
+		t.Errorf("want error of $GOLLECT_MINIFY, but got %v", err)
+	}
+}
+
+func TestDiscoverConfigLayers(t *testing.T) {
+	tmp := t.TempDir()
+	user := filepath.Join(tmp, "xdg", "gollect", "config.yml")
+	project := filepath.Join(tmp, "project", ProjectConfigName+".toml")
+	dir := filepath.Join(tmp, "project", "a", "b")
+
+	for path, content := range map[string]string{
+		user:    "sizeLimit: 100\nminify: true\n",
+		project: "sizeLimit = 200\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "xdg"))
+	t.Setenv("GOLLECT_BANNERS", "1")
+
+	if p := FindProjectConfig(dir); p != project {
+		t.Errorf("want %s, but got %s", project, p)
+	}
+
+	layers, err := DiscoverConfigLayers("", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ResolveConfig(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := r.Config; c.SizeLimit != 200 || !c.Minify || !c.Banners {
+		t.Errorf("want merged config, but got %+v", c)
+	}
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"inputFile: main.go # default",
+		"outputPaths: [stdout] # default",
+		"minify: true # " + user,
+		"banners: true # $GOLLECT_BANNERS",
+		"sizeLimit: 200 # " + project,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("want line %q in\n%s", line, b.String())
+		}
+	}
+
+	// the file given is used instead of the nearest one
+	if _, err := DiscoverConfigLayers(filepath.Join(tmp, "missing.yml"), dir); err == nil {
+		t.Error("want error for the missing config")
+	}
+}
+
+func TestUpperSnake(t *testing.T) {
+	for in, want := range map[string]string{
+		"outputPaths":                   "OUTPUT_PATHS",
+		"thirdPartyPackagePathPrefixes": "THIRD_PARTY_PACKAGE_PATH_PREFIXES",
+		"goos":                          "GOOS",
+	} {
+		if actual := upperSnake(in); actual != want {
+			t.Errorf("want %s, but got %s", want, actual)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LintIssue is a construct of a library package which gollect cannot
+// bundle, or which may break the output.
+type LintIssue struct {
+	Pos     token.Position
+	Message string
+	Fix     string // suggested fix
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s\n\tfix: %s", i.Pos, i.Message, i.Fix)
+}
+
+// Lint loads the packages matched by the patterns as same as ParseAll,
+// and reports constructs gollect cannot bundle. Main packages are
+// skipped, since they are not libraries.
+// The issues are sorted by positions.
+func Lint(config *Config, patterns ...string) ([]LintIssue, error) {
+	setBuildContext(config.GOOS, config.GOARCH)
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles,
+
+		// files importing "C" are ignored without cgo
+		Env:        append(buildEnv(), "CGO_ENABLED=1"),
+		BuildFlags: buildFlags(),
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	var errs []string
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("load (patterns = %v): %s", patterns, strings.Join(errs, "; "))
+	}
+
+	l := &linter{fset: token.NewFileSet(), names: make(map[string][]lintDecl)}
+	for _, p := range pkgs {
+		if p.Name == "main" {
+			continue
+		}
+		if err := l.lintPackage(p); err != nil {
+			return nil, err
+		}
+	}
+	l.lintCollisions()
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i].Pos, l.issues[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.issues, nil
+}
+
+type (
+	linter struct {
+		fset   *token.FileSet
+		names  map[string][]lintDecl // package-level declarations by name
+		issues []LintIssue
+	}
+
+	lintDecl struct {
+		pkg string
+		pos token.Pos
+	}
+)
+
+func (l *linter) report(pos token.Position, fix, format string, a ...interface{}) {
+	l.issues = append(l.issues, LintIssue{Pos: pos, Message: fmt.Sprintf(format, a...), Fix: fix})
+}
+
+func (l *linter) lintPackage(p *packages.Package) (err error) {
+	defer func() {
+		// ParseAst panics on syntax errors
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	for _, path := range p.OtherFiles {
+		if strings.EqualFold(filepath.Ext(path), ".s") {
+			l.report(
+				token.Position{Filename: path, Line: 1, Column: 1},
+				"implement the functions in Go, in a file constrained by `//go:build gollect` if the assembly is still needed locally",
+				"assembly file cannot be bundled",
+			)
+		}
+	}
+
+	pkg := NewPackage(p.PkgPath)
+	ParseAst(l.fset, pkg, p.GoFiles...)
+
+	for _, file := range pkg.files {
+		l.lintFile(file)
+		l.collectNames(pkg.Path(), file)
+	}
+	return nil
+}
+
+func (l *linter) lintFile(file *ast.File) {
+	for _, spec := range file.Imports {
+		path := strings.Trim(spec.Path.Value, "\"`")
+		pos := l.fset.Position(spec.Pos())
+
+		switch {
+		case path == "C":
+			l.report(pos, "implement it in Go", "cgo is not supported")
+		case spec.Name != nil && spec.Name.Name == ".":
+			l.report(pos, "import the package with its name and qualify the identifiers",
+				"dot import of %s is not supported", path)
+		case spec.Name != nil && spec.Name.Name == "_" && path != "embed":
+			l.report(pos, "import the package from the main package, or call its function explicitly",
+				"blank import of %s is not supported", path)
+		}
+	}
+
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:linkname") {
+				l.report(l.fset.Position(c.Pos()), "remove the directive and implement it with exported API",
+					"//go:linkname is not portable among judges")
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Body == nil {
+				l.report(l.fset.Position(decl.Pos()), "implement it in Go",
+					"function %s has no body, which is implemented in assembly or linked", decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.VAR {
+				l.lintEmbeds(decl)
+			}
+		}
+	}
+}
+
+// lintEmbeds reports //go:embed variables which cannot be inlined.
+// Only string and []byte are inlined.
+func (l *linter) lintEmbeds(gd *ast.GenDecl) {
+	for _, spec := range gd.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if len(embedPatterns(embedDocs(gd, vs)...)) == 0 {
+			continue
+		}
+
+		pos := l.fset.Position(vs.Pos())
+		switch embedKindOf(vs.Type) {
+		case embedFS:
+			l.report(pos, "embed each file as string or []byte",
+				"embed.FS %s cannot be bundled", vs.Names[0].Name)
+		case embedUnsupported:
+			l.report(pos, "declare it as string or []byte",
+				"unsupported type of //go:embed variable %s", vs.Names[0].Name)
+		}
+	}
+}
+
+// collectNames collects names of package-level declarations.
+// Methods are not collected, since they belong to their types.
+func (l *linter) collectNames(pkg string, file *ast.File) {
+	add := func(id *ast.Ident) {
+		switch id.Name {
+		case "_", "init":
+			return
+		}
+		l.names[id.Name] = append(l.names[id.Name], lintDecl{pkg: pkg, pos: id.Pos()})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				add(decl.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for _, id := range spec.Names {
+						add(id)
+					}
+				case *ast.TypeSpec:
+					add(spec.Name)
+				}
+			}
+		}
+	}
+}
+
+// lintCollisions reports names declared in more than one package,
+// because all packages are bundled into one file.
+func (l *linter) lintCollisions() {
+	for name, decls := range l.names {
+		pkgs := make(map[string]bool)
+		for _, d := range decls {
+			pkgs[d.pkg] = true
+		}
+		if len(pkgs) < 2 {
+			continue
+		}
+
+		for _, d := range decls {
+			var others []string
+			for p := range pkgs {
+				if p != d.pkg {
+					others = append(others, p)
+				}
+			}
+			sort.Strings(others)
+
+			l.report(l.fset.Position(d.pos),
+				"rename it, so that the packages can be used in the same solution",
+				"%s is also declared in %s", name, strings.Join(others, ", "))
+		}
+	}
+}
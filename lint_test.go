@@ -0,0 +1,55 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestLint(t *testing.T) {
+	root := testdata.FilePaths.Lint
+	issues, err := Lint(DefaultConfig(), root+"/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const pkg = "github.com/murosan/gollect/testdata/codes/lint/"
+
+	// the main package is not linted
+	want := []string{
+		"a/a.go:5:2: blank import of image/png is not supported",
+		"a/a.go:6:2: dot import of strings is not supported",
+		"a/a.go:14:5: embed.FS assets cannot be bundled",
+		"a/a.go:16:1: //go:linkname is not portable among judges",
+		"a/a.go:17:1: function nanotime has no body, which is implemented in assembly or linked",
+		"a/a.go:19:6: Max is also declared in " + pkg + "b",
+		"b/b.go:6:8: cgo is not supported",
+		"b/b.go:8:6: Max is also declared in " + pkg + "a",
+		"b/b.go:15:1: function sum has no body, which is implemented in assembly or linked",
+		"b/sum.s:1:1: assembly file cannot be bundled",
+	}
+
+	var actual []string
+	for _, i := range issues {
+		rel, err := filepath.Rel(root, i.Pos.Filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, fmt.Sprintf("%s:%d:%d: %s", filepath.ToSlash(rel), i.Pos.Line, i.Pos.Column, i.Message))
+
+		if i.Fix == "" {
+			t.Errorf("fix should be suggested: %s", i)
+		}
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(want, "\n") {
+		t.Errorf("\n[want]\n%s\n[actual]\n%s", strings.Join(want, "\n"), strings.Join(actual, "\n"))
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Minify shortens unexported identifiers of library packages.
+// Write also removes comments with CommentsNone and collapses blank
+// lines after formatting when minifying.
+//
+// Renamed identifiers are package-level functions, variables, constants
+// and types, and local ones. Exported identifiers, struct fields and
+// methods are never renamed, because they may be used by reflection
+// (e.g, encoding/json) or required by interfaces. Types embedded in
+// structs are not renamed either, since their names are field names.
+func Minify(program *Program) {
+	pset := program.PackageSet()
+
+	reserved := make(map[string]bool)
+	for _, name := range types.Universe.Names() {
+		reserved[name] = true
+	}
+	for _, pkg := range pset {
+		for _, file := range pkg.files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					reserved[id.Name] = true
+				}
+				return true
+			})
+		}
+	}
+
+	var libs []*Package
+	for _, path := range sortedPackagePaths(pset, program.EntryPackage()) {
+		if path != program.EntryPackage() {
+			libs = append(libs, pset[path])
+		}
+	}
+
+	// package-level names are generated first, so that local names
+	// generated after them never shadow them.
+	gen := &nameGenerator{reserved: reserved}
+	renames := make(map[types.Object]string)
+	for _, pkg := range libs {
+		renamePackageLevel(pkg, gen, renames)
+	}
+	for _, pkg := range libs {
+		renameLocals(pkg, gen, renames)
+	}
+	for _, pkg := range libs {
+		applyRenames(pkg, renames)
+	}
+}
+
+// renamePackageLevel generates names for unexported package-level
+// identifiers in order of declarations.
+func renamePackageLevel(pkg *Package, gen *nameGenerator, renames map[types.Object]string) {
+	info := pkg.Info()
+	embedded := embeddedTypes(pkg)
+
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			ast.Inspect(decl, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := info.Defs[id]
+				if isRenamable(obj) && !embedded[obj] && isPackageLevel(obj) {
+					renames[obj] = gen.next()
+				}
+				return true
+			})
+		}
+	}
+}
+
+// renameLocals generates names for local identifiers of each
+// declaration. The names are reused among declarations.
+func renameLocals(pkg *Package, gen *nameGenerator, renames map[types.Object]string) {
+	info := pkg.Info()
+	embedded := embeddedTypes(pkg)
+
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			local := gen.fork()
+			ast.Inspect(decl, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.Ident:
+					obj := info.Defs[n]
+					if isRenamable(obj) && !embedded[obj] && !isPackageLevel(obj) {
+						renames[obj] = local.next()
+					}
+
+				case *ast.TypeSwitchStmt:
+					// the symbolic variable of `switch v := x.(type)` has
+					// no object, but implicit ones for each clause.
+					assign, ok := n.Assign.(*ast.AssignStmt)
+					if !ok {
+						break
+					}
+					id := assign.Lhs[0].(*ast.Ident)
+					if id.Name == "_" {
+						break
+					}
+					id.Name = local.next()
+					for _, c := range n.Body.List {
+						if obj := info.Implicits[c]; obj != nil {
+							renames[obj] = id.Name
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+func applyRenames(pkg *Package, renames map[types.Object]string) {
+	info := pkg.Info()
+	for _, file := range pkg.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := info.Defs[id]
+			if obj == nil {
+				obj = info.Uses[id]
+			}
+			if name, ok := renames[obj]; ok {
+				id.Name = name
+			}
+			return true
+		})
+	}
+}
+
+// embeddedTypes returns types embedded in structs of the package.
+func embeddedTypes(pkg *Package) map[types.Object]bool {
+	info := pkg.Info()
+	embedded := make(map[types.Object]bool)
+	for _, file := range pkg.files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if st, ok := n.(*ast.StructType); ok {
+				for _, f := range st.Fields.List {
+					if len(f.Names) == 0 {
+						embedded[info.Uses[embeddedTypeIdent(f.Type)]] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	return embedded
+}
+
+func isPackageLevel(obj types.Object) bool {
+	return obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+}
+
+func isRenamable(obj types.Object) bool {
+	if obj == nil || obj.Exported() {
+		return false
+	}
+	switch name := obj.Name(); name {
+	case "_", "init", "main":
+		return false
+	}
+
+	switch obj := obj.(type) {
+	case *types.Var:
+		return !obj.IsField()
+	case *types.Func:
+		return obj.Type().(*types.Signature).Recv() == nil
+	case *types.Const, *types.TypeName:
+		return true
+	}
+	return false
+}
+
+func embeddedTypeIdent(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.StarExpr:
+		return embeddedTypeIdent(expr.X)
+	case *ast.IndexExpr:
+		return embeddedTypeIdent(expr.X)
+	case *ast.IndexListExpr:
+		return embeddedTypeIdent(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel
+	}
+	return nil
+}
+
+// collapseBlankLines removes all blank lines except the ones in raw
+// string literals.
+// The source should be formatted again, because some of them are
+// required by gofmt.
+func collapseBlankLines(src []byte) []byte {
+	// ranges of multi-line raw string literals
+	var raws [][2]int
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.STRING && strings.HasPrefix(lit, "`") && strings.Contains(lit, "\n") {
+			start := file.Offset(pos)
+			raws = append(raws, [2]int{start, start + len(lit)})
+		}
+	}
+
+	inRaw := func(offset int) bool {
+		for _, r := range raws {
+			if r[0] < offset && offset < r[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var buf bytes.Buffer
+	offset := 0
+	for _, line := range bytes.SplitAfter(src, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) != 0 || inRaw(offset) {
+			buf.Write(line)
+		}
+		offset += len(line)
+	}
+	return buf.Bytes()
+}
+
+// nameGenerator generates short identifiers in order of
+// a, b, ..., z, aa, ab, ... skipping the reserved names and keywords.
+type nameGenerator struct {
+	reserved map[string]bool
+	n        int
+}
+
+func (g *nameGenerator) next() string {
+	for {
+		name := shortName(g.n)
+		g.n++
+		if !g.reserved[name] && !token.IsKeyword(name) {
+			return name
+		}
+	}
+}
+
+// fork returns new generator generates names after g.
+func (g *nameGenerator) fork() *nameGenerator {
+	return &nameGenerator{reserved: g.reserved, n: g.n}
+}
+
+func shortName(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+
+	var b []byte
+	for n++; n > 0; n = (n - 1) / len(letters) {
+		b = append([]byte{letters[(n-1)%len(letters)]}, b...)
+	}
+	return string(b)
+}
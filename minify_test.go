@@ -0,0 +1,44 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"testing"
+)
+
+func TestNameGenerator(t *testing.T) {
+	gen := &nameGenerator{reserved: map[string]bool{"b": true}}
+
+	var names []string
+	for i := 0; i < 30; i++ {
+		names = append(names, gen.next())
+	}
+
+	for i, want := range map[int]string{0: "a", 1: "c", 24: "z", 25: "aa", 26: "ab"} {
+		if names[i] != want {
+			t.Errorf("at: %d, want: %s, actual: %s", i, want, names[i])
+		}
+	}
+
+	gen = &nameGenerator{n: 26 + 26*26 + 5} // index of "aaf"
+	if name := gen.fork().next(); name != "aaf" {
+		t.Errorf("want: aaf, actual: %s", name)
+	}
+
+	// keywords are skipped
+	gen = &nameGenerator{n: 26 + 8*26 + 5} // index of "if"
+	if name := gen.next(); name != "ig" {
+		t.Errorf("want: ig, actual: %s", name)
+	}
+}
+
+func TestCollapseBlankLines(t *testing.T) {
+	in := "package main\n\nconst s = `a\n\nb`\n\nfunc main() {\n\n\tprintln(s)\n}\n"
+	want := "package main\nconst s = `a\n\nb`\nfunc main() {\n\tprintln(s)\n}\n"
+
+	if actual := string(collapseBlankLines([]byte(in))); actual != want {
+		t.Errorf("\n[want]\n%q\n[actual]\n%q", want, actual)
+	}
+}
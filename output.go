@@ -0,0 +1,219 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+)
+
+// OutputTarget is a destination of the output.
+type OutputTarget interface {
+	// WriteOutput writes the whole output to the destination.
+	WriteOutput(b []byte) error
+}
+
+// OutputTargetFactory returns the target of the output path.
+// The argument is the path without the scheme,
+// e.g, "out/c.go" for "file:out/c.go".
+type OutputTargetFactory func(arg string) (OutputTarget, error)
+
+var (
+	outputMu      sync.RWMutex
+	outputTargets = map[string]OutputTargetFactory{
+		"file":      newFileTarget,
+		"stdout":    func(string) (OutputTarget, error) { return &stdoutTarget{}, nil },
+		"clipboard": func(string) (OutputTarget, error) { return &clipboardTarget{}, nil },
+		"cmd":       newCmdTarget,
+		"osc52":     func(string) (OutputTarget, error) { return &osc52Target{}, nil },
+	}
+)
+
+// RegisterOutputTarget registers the factory of output targets for the
+// scheme. It panics if the scheme is already registered.
+func RegisterOutputTarget(scheme string, f OutputTargetFactory) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if !outputScheme.MatchString(scheme + ":") {
+		panic(fmt.Sprintf("invalid output scheme: %s", scheme))
+	}
+	if _, ok := outputTargets[scheme]; ok {
+		panic(fmt.Sprintf("output scheme %s is already registered", scheme))
+	}
+	outputTargets[scheme] = f
+}
+
+// OutputSchemes returns the registered schemes in sorted order.
+func OutputSchemes() []string {
+	outputMu.RLock()
+	defer outputMu.RUnlock()
+
+	schemes := make([]string, 0, len(outputTargets))
+	for s := range outputTargets {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// outputScheme matches the scheme of output paths. A single letter is
+// not a scheme, so that Windows paths such as C:\out.go are files.
+var outputScheme = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+):`)
+
+// NewOutputTarget returns the target of the output path, which is a URI
+// such as "cmd:pbcopy". "stdout" and "clipboard" are the same as
+// "stdout:" and "clipboard:". Paths without registered scheme such as
+// "out:v2.go" are files.
+func NewOutputTarget(path string) (OutputTarget, error) {
+	scheme, arg := "file", path
+	switch lower := strings.ToLower(path); {
+	case lower == "stdout" || lower == "clipboard":
+		scheme, arg = lower, ""
+	case outputScheme.MatchString(path):
+		m := outputScheme.FindStringSubmatch(path)
+		if s := strings.ToLower(m[1]); isOutputScheme(s) {
+			scheme, arg = s, path[len(m[0]):]
+		}
+	}
+
+	outputMu.RLock()
+	f := outputTargets[scheme]
+	outputMu.RUnlock()
+	return f(arg)
+}
+
+func isOutputScheme(scheme string) bool {
+	outputMu.RLock()
+	defer outputMu.RUnlock()
+	_, ok := outputTargets[scheme]
+	return ok
+}
+
+type stdoutTarget struct{}
+
+func (t *stdoutTarget) WriteOutput(b []byte) error {
+	_, err := os.Stdout.Write(b)
+	return err
+}
+
+type clipboardTarget struct{}
+
+func (t *clipboardTarget) WriteOutput(b []byte) error {
+	if clipboard.Unsupported {
+		return errors.New("no support for clipboard")
+	}
+	return clipboard.WriteAll(string(b))
+}
+
+// fileTarget writes the file atomically, writing to a temporary file in
+// the same directory and renaming it. Directories are created.
+type fileTarget struct{ path string }
+
+func newFileTarget(path string) (OutputTarget, error) {
+	if path == "" {
+		return nil, errors.New("output path is empty")
+	}
+	return &fileTarget{path: path}, nil
+}
+
+func (t *fileTarget) WriteOutput(b []byte) (err error) {
+	dir := filepath.Dir(t.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(t.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// the temporary file is created with 0600
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(t.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), t.path)
+}
+
+// cmdTarget pipes the output into the command run by the shell.
+type cmdTarget struct{ command string }
+
+func newCmdTarget(command string) (OutputTarget, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, errors.New("command is empty")
+	}
+	return &cmdTarget{command: command}, nil
+}
+
+func (t *cmdTarget) WriteOutput(b []byte) error {
+	cmd := exec.Command("sh", "-c", t.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", t.command)
+	}
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", t.command, err)
+	}
+	return nil
+}
+
+// osc52Target copies the output to the clipboard of the terminal with
+// the OSC 52 escape sequence, which works over SSH.
+// The sequence is written to the controlling terminal, or stderr if
+// there is no terminal.
+type osc52Target struct{}
+
+func (t *osc52Target) WriteOutput(b []byte) error {
+	var w io.Writer = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		w = tty
+	}
+	_, err := io.WriteString(w, osc52(b, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// osc52 returns the escape sequence setting the clipboard to b.
+// In tmux, the sequence is passed through to the outer terminal.
+func osc52(b []byte, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString(b) + "\a"
+	if tmux {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewOutputTarget(t *testing.T) {
+	cases := []struct {
+		path string
+		want OutputTarget
+	}{
+		{"clipboard", &clipboardTarget{}},
+		{"CLIPBOARD", &clipboardTarget{}},
+		{"ClipBoard", &clipboardTarget{}},
+		{"clipboard:", &clipboardTarget{}},
+		{"stdout", &stdoutTarget{}},
+		{"StdOut", &stdoutTarget{}},
+		{"stdout:", &stdoutTarget{}},
+		{"file", &fileTarget{path: "file"}},
+		{"out/c.go", &fileTarget{path: "out/c.go"}},
+		{"file:stdout", &fileTarget{path: "stdout"}},
+		{`C:\out\c.go`, &fileTarget{path: `C:\out\c.go`}},
+		{"cmd:xclip -selection clipboard", &cmdTarget{command: "xclip -selection clipboard"}},
+		{"osc52:", &osc52Target{}},
+		{"out:v2.go", &fileTarget{path: "out:v2.go"}},
+		{"FTP:out.go", &fileTarget{path: "FTP:out.go"}},
+	}
+	for i, c := range cases {
+		actual, err := NewOutputTarget(c.path)
+		if err != nil {
+			t.Errorf("at: %d, %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, c.want) {
+			t.Errorf("at: %d, want: %#v, actual: %#v", i, c.want, actual)
+		}
+	}
+
+	for i, path := range []string{"cmd:", "file:"} {
+		if _, err := NewOutputTarget(path); err == nil {
+			t.Errorf("at: %d, want error for %s", i, path)
+		}
+	}
+}
+
+func TestRegisterOutputTarget(t *testing.T) {
+	var got []string
+	RegisterOutputTarget("test-memory", func(arg string) (OutputTarget, error) {
+		return outputTargetFunc(func(b []byte) error {
+			got = append(got, arg+"="+string(b))
+			return nil
+		}), nil
+	})
+	defer func() {
+		outputMu.Lock()
+		delete(outputTargets, "test-memory")
+		outputMu.Unlock()
+	}()
+
+	shouldPanic(t, func() {
+		RegisterOutputTarget("file", newFileTarget)
+	}, "should fail for registered scheme")
+
+	dir := t.TempDir()
+	w := &writer{config: &Config{OutputPaths: []string{
+		"Test-Memory:a",
+		"cmd:",
+		filepath.Join(dir, "a", "b", "c.go"),
+		"cmd:exit 3",
+		"test-memory:b",
+	}}}
+	w.Write([]byte("src"))
+
+	err := w.writeForeach()
+	if want := []string{"a=src", "b=src"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, but got %v", want, got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "write cmd:: command is empty") ||
+		!strings.Contains(err.Error(), "write cmd:exit 3: exit 3:") {
+		t.Errorf("want errors of cmd, but got %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "a", "b", "c.go"))
+	if err != nil || string(b) != "src" {
+		t.Errorf("want src written to the file, but got %q, %v", b, err)
+	}
+}
+
+func TestFileTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "c.go")
+	if err := os.WriteFile(path, []byte("old content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	target, _ := NewOutputTarget(path)
+	if err := target.WriteOutput([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != "new" {
+		t.Errorf("want new, but got %q", b)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("want the mode kept, but got %v", info.Mode())
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("want no temporary file left, but got %v", entries)
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	if s := osc52([]byte("abc"), false); s != "\x1b]52;c;YWJj\a" {
+		t.Errorf("got %q", s)
+	}
+	if s := osc52([]byte("abc"), true); s != "\x1bPtmux;\x1b\x1b]52;c;YWJj\a\x1b\\" {
+		t.Errorf("got %q", s)
+	}
+}
+
+type outputTargetFunc func(b []byte) error
+
+func (f outputTargetFunc) WriteOutput(b []byte) error { return f(b) }
@@ -15,6 +15,13 @@ type Package struct {
 	files   []*ast.File            // container of ast files
 	objects map[string]*ast.Object // map of package-level objects
 	info    *types.Info            // uses info
+	types   *types.Package         // type-checked or read from export data
+
+	cached bool                 // true if restored from PackageCache
+	deps   map[string][]*Import // imports each decl depends on, restored from cache
+	export []byte               // export data of types, restored from cache
+
+	stripped []strippedStmt // statements removed by Stripper
 }
 
 // NewPackage returns new Package.
@@ -28,6 +35,8 @@ func NewPackage(path string) *Package {
 			// Types:      make(map[ast.Expr]types.TypeAndValue),
 			Defs:       make(map[*ast.Ident]types.Object),
 			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Implicits:  make(map[ast.Node]types.Object),
+			Instances:  make(map[*ast.Ident]types.Instance),
 		},
 	}
 }
@@ -60,6 +69,10 @@ func (pkg *Package) PushAstFile(f *ast.File) {
 
 func (pkg *Package) Info() *types.Info { return pkg.info }
 
+// IsCached returns true if the declarations of the package are
+// restored from PackageCache instead of analyzing.
+func (pkg *Package) IsCached() bool { return pkg.cached }
+
 // PackageSet is a map of Package.
 type PackageSet map[string]*Package
 
@@ -8,8 +8,8 @@ import (
 	"fmt"
 	"go/parser"
 	"go/token"
-	"os"
 	"strconv"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -21,29 +21,32 @@ func ParseAll(
 	initialPackage string,
 	initialFilePaths []string,
 ) {
-	find := func(path string) []string {
-		if path == initialPackage {
-			return initialFilePaths
-		}
-		return FindFilePaths(path)
+	ParseDependencies(program, ParsePackage(program, initialPackage, initialFilePaths))
+}
+
+// ParsePackage parses ast files of the package and sets to Program's map.
+func ParsePackage(program *Program, path string, filePaths []string) *Package {
+	pkg := NewPackage(path)
+	program.PackageSet().Add(path, pkg)
+
+	ParseAst(program.FileSet(), pkg, filePaths...)
+	if len(pkg.files) == 0 {
+		panic(fmt.Sprintf("there are no files. paths=%v", filePaths))
 	}
+	return pkg
+}
 
-	for paths := []string{initialPackage}; len(paths) > 0; paths = paths[1:] {
+// ParseDependencies parses ast files of the packages imported from pkg
+// recursively, and sets to Program's map.
+func ParseDependencies(program *Program, pkg *Package) {
+	for paths := NextPackagePaths(pkg); len(paths) > 0; paths = paths[1:] {
 		path := paths[0]
 		if _, ok := program.PackageSet().Get(path); ok {
 			continue
 		}
 
-		pkg := NewPackage(path)
-		program.PackageSet().Add(path, pkg)
-
-		fp := find(path)
-		ParseAst(program.FileSet(), pkg, fp...)
-
-		if len(pkg.files) == 0 {
-			panic(fmt.Sprintf("there are no files. paths=%v", fp))
-		}
-		paths = append(paths, NextPackagePaths(pkg)...)
+		dep := ParsePackage(program, path, program.PackageCache().FilePaths(path))
+		paths = append(paths, NextPackagePaths(dep)...)
 	}
 }
 
@@ -61,14 +64,24 @@ func ParseAst(fset *token.FileSet, p *Package, paths ...string) {
 // FindFilePaths finds filepaths from package path.
 // https://pkg.go.dev/golang.org/x/tools/go/packages?tab=doc#example-package
 func FindFilePaths(path string) (paths []string) {
-	cfg := &packages.Config{Mode: packages.NeedFiles | packages.NeedSyntax}
+	cfg := &packages.Config{
+		Mode:       packages.NeedFiles | packages.NeedSyntax,
+		Env:        buildEnv(),
+		BuildFlags: buildFlags(),
+	}
 	pkgs, err := packages.Load(cfg, path)
 	if err != nil {
 		panic(fmt.Errorf("load: %w", err))
 	}
 
-	if packages.PrintErrors(pkgs) > 0 {
-		os.Exit(1)
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			errs = append(errs, err.Error())
+		}
+	})
+	if len(errs) > 0 {
+		panic(fmt.Errorf("load (path = %s): %s", path, strings.Join(errs, "; ")))
 	}
 
 	for _, pkg := range pkgs {
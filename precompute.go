@@ -0,0 +1,419 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Precomputer replaces the initializers of variables annotated with
+// `// gollect: precompute` with the literals of their values, before
+// resolving dependencies. So the declarations used only from the
+// initializers are removed as unused.
+//
+//	// gollect: precompute
+//	var primes = sieve(100) → var primes = []int{2, 3, 5, ...}
+//
+// The nil value is a valid Precomputer that replaces nothing.
+type Precomputer struct {
+	values map[string]string // literals by package path and variable name
+}
+
+// Package replaces the initializers of the package files.
+func (c *Precomputer) Package(pkg *Package) {
+	if c == nil {
+		return
+	}
+
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				spec := spec.(*ast.ValueSpec)
+				lit, ok := c.values[pkg.path+"."+spec.Names[0].Name]
+				if !ok || len(spec.Values) != 1 {
+					continue
+				}
+
+				// the literal is not a basic literal, but it is printed
+				// as it is. It has no identifiers to resolve, since
+				// only unnamed types of basic types are supported.
+				spec.Type = nil
+				spec.Values = []ast.Expr{&ast.BasicLit{
+					ValuePos: spec.Values[0].Pos(),
+					Kind:     token.STRING,
+					Value:    lit,
+				}}
+			}
+		}
+	}
+}
+
+func (c *Precomputer) String() string {
+	if c == nil {
+		return "Precomputer{}"
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("Precomputer{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s:%s", k, c.values[k])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// precomputeVar is a variable annotated with precompute.
+type precomputeVar struct {
+	key  string // package path and variable name
+	pos  token.Position
+	spec *ast.ValueSpec
+}
+
+// precomputeProgram computes the values of the variables annotated
+// with precompute and used, then parses and analyzes the program again
+// with the values.
+// It does nothing if there is no such variable.
+func precomputeProgram(p *Program, config *Config) error {
+	vars, err := precomputeVars(p)
+	if err != nil || len(vars) == 0 {
+		return err
+	}
+
+	c, err := computeValues(p, vars, config.SizeLimit)
+	if err != nil {
+		return err
+	}
+
+	p.reset()
+	p.SetPrecomputer(c)
+	if err := parse(p, config); err != nil {
+		return err
+	}
+	return analyze(p, config)
+}
+
+// precomputeVars returns the variables annotated with precompute and
+// used. The initializer of each variable must be a function call.
+func precomputeVars(p *Program) ([]precomputeVar, error) {
+	fset, dset := p.FileSet(), p.DeclSet()
+
+	var vars []precomputeVar
+	for _, path := range sortedPackagePaths(p.PackageSet(), p.EntryPackage()) {
+		pkg := p.PackageSet()[path]
+		for _, file := range pkg.files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.VAR {
+					continue
+				}
+
+				for _, spec := range gd.Specs {
+					spec := spec.(*ast.ValueSpec)
+					if !parseAnnotations(gd.Doc, spec.Doc).precompute {
+						continue
+					}
+
+					name := spec.Names[0].Name
+					if d, ok := dset.Get(pkg, name); !ok || !d.IsUsed() {
+						continue
+					}
+
+					pos := fset.Position(spec.Pos())
+					if len(spec.Names) != 1 || len(spec.Values) != 1 {
+						return nil, fmt.Errorf("%s: precompute %s: declare the variable alone", pos, name)
+					}
+					if _, ok := spec.Values[0].(*ast.CallExpr); !ok {
+						return nil, fmt.Errorf("%s: precompute %s: the initializer must be a function call", pos, name)
+					}
+					vars = append(vars, precomputeVar{key: path + "." + name, pos: pos, spec: spec})
+				}
+			}
+		}
+	}
+	return vars, nil
+}
+
+// computeValues writes the program, and runs it as a helper program
+// printing the literals of the variables instead of calling main.
+// The size of each literal must not exceed the limit, unless it is 0.
+func computeValues(p *Program, vars []precomputeVar, limit int) (*Precomputer, error) {
+	// written as a plain main package, keeping the names minified
+	opts := p.WriteOptions()
+	p.SetWriteOptions(WriteOptions{Minify: opts.Minify, Comments: CommentsNone})
+	defer p.SetWriteOptions(opts)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, p); err != nil {
+		return nil, fmt.Errorf("precompute: %w", err)
+	}
+
+	// the names are available after writing, since minifying renames
+	names := make([]string, len(vars))
+	for i, v := range vars {
+		names[i] = v.spec.Names[0].Name
+	}
+
+	pkg := p.PackageSet()[p.EntryPackage()]
+	dir := filepath.Dir(p.FileSet().File(pkg.files[0].Pos()).Name())
+
+	results, err := runPrecompute(buf.Bytes(), names, dir)
+	if err != nil {
+		return nil, fmt.Errorf("precompute: %w", err)
+	}
+
+	c := &Precomputer{values: make(map[string]string)}
+	for i, v := range vars {
+		res := results[i]
+		name := v.spec.Names[0].Name
+		if res.Error != "" {
+			return nil, fmt.Errorf("%s: precompute %s: %s", v.pos, name, res.Error)
+		}
+		if limit > 0 && len(res.Literal) > limit {
+			return nil, fmt.Errorf("%s: precompute %s: the literal is %d bytes, which exceeds the size limit %d bytes",
+				v.pos, name, len(res.Literal), limit)
+		}
+		c.values[v.key] = res.Literal
+	}
+	return c, nil
+}
+
+// precomputeResult is a result of a variable reported by the helper.
+type precomputeResult struct {
+	Literal string
+	Error   string
+}
+
+// runPrecompute builds the source with the helper in a temporary
+// directory with the requirements of the module of dir, and returns
+// the results of the variables.
+func runPrecompute(src []byte, names []string, dir string) ([]precomputeResult, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	// main is not called, but left for the declarations it uses
+	file.Name.Name = "main"
+	for _, decl := range file.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.Name == "main" {
+			fd.Name.Name = "_"
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "gollect-precompute-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, file); err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "main.go"), b.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]string, len(names))
+	for i, name := range names {
+		ptrs[i] = "&" + name
+	}
+	helper := fmt.Sprintf(precomputeHelper, strings.Join(ptrs, ", "))
+	if err := os.WriteFile(filepath.Join(tmp, "gollect_precompute.go"), []byte(helper), 0o644); err != nil {
+		return nil, err
+	}
+	if err := writeModule(dir, tmp); err != nil {
+		return nil, err
+	}
+
+	out := filepath.Join(tmp, "results.json")
+	args := append([]string{"run"}, buildFlags()...)
+	cmd := exec.Command("go", append(args, ".", out)...)
+	cmd.Dir = tmp
+	cmd.Env = buildEnv()
+	if msg, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("run: %w\n%s", err, strings.TrimSpace(stripDir(string(msg), tmp)))
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	var results []precomputeResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("read results: %w", err)
+	}
+	if len(results) != len(names) {
+		return nil, fmt.Errorf("read results: %d results for %d variables", len(results), len(names))
+	}
+	return results, nil
+}
+
+// precomputeHelper is the source of the helper, which writes the
+// literals of the variables to the file given as the argument.
+// The variables are passed as pointers, so that the static types are
+// checked. The names are prefixed so as not to collide with the bundle.
+const precomputeHelper = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	vars := []interface{}{%s}
+
+	results := make([]struct{ Literal, Error string }, len(vars))
+	for i, v := range vars {
+		lit, err := gollectPrecomputeLiteral(reflect.ValueOf(v).Elem(), false)
+		if err != nil {
+			results[i].Error = err.Error()
+		}
+		results[i].Literal = lit
+	}
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(os.Args[1], b, 0o644); err != nil {
+		panic(err)
+	}
+}
+
+// gollectPrecomputeType returns the type expression.
+func gollectPrecomputeType(t reflect.Type) (string, error) {
+	if t.PkgPath() != "" {
+		return "", fmt.Errorf("unsupported type %%s. only unnamed types of basic types are supported", t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Name(), nil
+	case reflect.Slice:
+		e, err := gollectPrecomputeType(t.Elem())
+		return "[]" + e, err
+	case reflect.Array:
+		e, err := gollectPrecomputeType(t.Elem())
+		return fmt.Sprintf("[%%d]%%s", t.Len(), e), err
+	case reflect.Map:
+		k, err := gollectPrecomputeType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		v, err := gollectPrecomputeType(t.Elem())
+		return "map[" + k + "]" + v, err
+	}
+	return "", fmt.Errorf("unsupported type %%s. only slices, arrays and maps of basic types are supported", t)
+}
+
+// gollectPrecomputeLiteral returns the literal of v. The type is
+// omitted if it is an element of a composite literal.
+func gollectPrecomputeLiteral(v reflect.Value, elem bool) (string, error) {
+	t, err := gollectPrecomputeType(v.Type())
+	if err != nil {
+		return "", err
+	}
+
+	// s is empty if v is not a basic type
+	var s string
+	switch v.Kind() {
+	case reflect.Bool:
+		s = strconv.FormatBool(v.Bool())
+	case reflect.String:
+		s = strconv.Quote(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%%v cannot be written as a literal", f)
+		}
+		s = strconv.FormatFloat(f, 'g', -1, v.Type().Bits())
+	}
+	if s != "" {
+		if elem || t == "int" || t == "string" || t == "bool" {
+			return s, nil
+		}
+		return t + "(" + s + ")", nil
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		if elem {
+			return "nil", nil
+		}
+		return t + "(nil)", nil
+	}
+
+	var elems []string
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			e, err := gollectPrecomputeLiteral(v.Index(i), true)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, e)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := gollectPrecomputeLiteral(iter.Key(), true)
+			if err != nil {
+				return "", err
+			}
+			e, err := gollectPrecomputeLiteral(iter.Value(), true)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, k+": "+e)
+		}
+		sort.Strings(elems)
+	}
+
+	s = "{" + strings.Join(elems, ", ") + "}"
+	if elem {
+		return s, nil
+	}
+	return t + s, nil
+}
+`
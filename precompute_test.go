@@ -0,0 +1,59 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestPrecomputeError(t *testing.T) {
+	cases := []struct {
+		dir   string
+		limit int
+		want  string
+	}{
+		{
+			dir:  "named",
+			want: "main.go:8:5: precompute table: unsupported type main.Table",
+		},
+		{
+			dir:  "pointer",
+			want: "main.go:6:5: precompute p: unsupported type *int",
+		},
+		{
+			dir:   "limit",
+			limit: 100,
+			want:  "main.go:6:5: precompute a: the literal is 395 bytes, which exceeds the size limit 100 bytes",
+		},
+	}
+
+	for _, c := range cases {
+		conf := DefaultConfig()
+		conf.InputFile = filepath.Join(testdata.FilePaths.Precompute, c.dir, "main.go")
+		conf.SizeLimit = c.limit
+		conf.CacheDir = t.TempDir()
+
+		var buf bytes.Buffer
+		p := newProgram(conf)
+		err := bundle(p, conf, &buf)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: want error containing %q, but got %v", c.dir, c.want, err)
+		}
+	}
+}
+
+func TestInvalidPrecomputeAnnotation(t *testing.T) {
+	program := NewProgram()
+	ParseAll(program, "main", []string{filepath.Join(testdata.FilePaths.Precompute, "const", "main.go")})
+
+	shouldPanic(t, func() {
+		AnalyzeForeach(program, "main", "main")
+	}, "should fail because constants cannot be precomputed")
+}
@@ -5,7 +5,9 @@
 package gollect
 
 import (
+	"go/importer"
 	"go/token"
+	"go/types"
 )
 
 // Program is a container of information that is necessary across packages.
@@ -14,15 +16,26 @@ type Program struct {
 	iset *ImportSet
 	dset DeclSet
 	pset PackageSet
+
+	cache    *PackageCache
+	importer types.Importer
+	stripper *Stripper
+	precomp  *Precomputer
+
+	entry string // package path of the entry
+	wopts WriteOptions
 }
 
 // NewProgram returns new Program.
 func NewProgram() *Program {
+	fset := token.NewFileSet()
 	return &Program{
-		fset: token.NewFileSet(),
-		iset: NewImportSet(),
-		dset: NewDeclSet(),
-		pset: make(PackageSet),
+		fset:     fset,
+		iset:     NewImportSet(),
+		dset:     NewDeclSet(),
+		pset:     make(PackageSet),
+		importer: importer.ForCompiler(fset, "source", nil),
+		entry:    "main",
 	}
 }
 
@@ -37,3 +50,55 @@ func (p *Program) DeclSet() DeclSet { return p.dset }
 
 // PackageSet returns packages.
 func (p *Program) PackageSet() PackageSet { return p.pset }
+
+// PackageCache returns the cache of analyzed library packages.
+// The value may be nil, which means caching is disabled.
+func (p *Program) PackageCache() *PackageCache { return p.cache }
+
+// SetPackageCache sets the cache of analyzed library packages.
+func (p *Program) SetPackageCache(c *PackageCache) { p.cache = c }
+
+// Importer returns the importer used for type checking the packages
+// not parsed, such as standard packages.
+func (p *Program) Importer() types.Importer { return p.importer }
+
+// SetImporter sets the importer used for type checking the packages
+// not parsed. The importer may be shared across programs to reuse
+// the type-checked packages.
+func (p *Program) SetImporter(i types.Importer) { p.importer = i }
+
+// Stripper returns the Stripper applied before resolving dependencies.
+func (p *Program) Stripper() *Stripper { return p.stripper }
+
+// SetStripper sets the Stripper applied before resolving dependencies.
+func (p *Program) SetStripper(s *Stripper) { p.stripper = s }
+
+// Precomputer returns the Precomputer applied before resolving
+// dependencies.
+func (p *Program) Precomputer() *Precomputer { return p.precomp }
+
+// SetPrecomputer sets the Precomputer applied before resolving
+// dependencies.
+func (p *Program) SetPrecomputer(c *Precomputer) { p.precomp = c }
+
+// EntryPackage returns the package path of the entry.
+// "main" means the package of input files.
+func (p *Program) EntryPackage() string { return p.entry }
+
+// SetEntryPackage sets the package path of the entry.
+func (p *Program) SetEntryPackage(path string) { p.entry = path }
+
+// WriteOptions returns options used by Write.
+func (p *Program) WriteOptions() WriteOptions { return p.wopts }
+
+// SetWriteOptions sets options used by Write.
+func (p *Program) SetWriteOptions(o WriteOptions) { p.wopts = o }
+
+// reset discards the parsed packages and the analysis, keeping the
+// settings, so that the program can be parsed again.
+func (p *Program) reset() {
+	p.fset = token.NewFileSet()
+	p.iset = NewImportSet()
+	p.dset = NewDeclSet()
+	p.pset = make(PackageSet)
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	rtdebug "runtime/debug"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+const modulePath = "github.com/murosan/gollect"
+
+// banner returns the comment printed before the declarations from
+// the file of the package.
+//
+//	// --- from github.com/owner/repo/lib (lib.go) ---
+func banner(pkgPath, filename string) string {
+	return fmt.Sprintf("// --- from %s (%s) ---\n", pkgPath, filepath.Base(filename))
+}
+
+// header returns the comments printed at the head of the output.
+// It records the version of gollect, VCS revisions of the library
+// modules and the hash of input files.
+//
+//	// bundled by gollect v1.0.0
+//	// library github.com/owner/repo 0123456789ab
+//	// inputs sha256:0123...
+func header(program *Program) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// bundled by gollect %s\n", version())
+
+	for _, m := range libraryModules(program) {
+		fmt.Fprintf(&b, "// library %s %s\n", m.path, m.revision)
+	}
+
+	fmt.Fprintf(&b, "// inputs sha256:%s\n", inputHash(program))
+	return b.String()
+}
+
+// version returns the version of gollect read from the build info.
+// The VCS revision is used for development builds.
+func version() string {
+	info, ok := rtdebug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	mod := &info.Main
+	if mod.Path != modulePath {
+		// used as a library
+		mod = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				mod = dep
+				break
+			}
+		}
+		if mod == nil {
+			return "unknown"
+		}
+	}
+
+	if mod.Version != "" && mod.Version != "(devel)" {
+		return mod.Version
+	}
+
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return "(devel) " + shortRevision(s.Value)
+		}
+	}
+	return "(devel)"
+}
+
+// inputHash returns the hash of all files parsed.
+func inputHash(program *Program) string {
+	var paths []string
+	for _, pkg := range program.PackageSet() {
+		for _, file := range pkg.files {
+			paths = append(paths, program.FileSet().File(file.Pos()).Name())
+		}
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			// the path is recorded at least
+			b = nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.Base(path), len(b))
+		h.Write(b)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+type libraryModule struct {
+	path, revision string
+}
+
+// libraryModules returns modules of library packages with their
+// VCS revisions, or versions for the modules in the module cache.
+// The revision is "unknown" if neither is found.
+func libraryModules(program *Program) (mods []libraryModule) {
+	seen := make(map[string]bool)
+	for _, path := range sortedPackagePaths(program.PackageSet(), program.EntryPackage()) {
+		pkg := program.PackageSet()[path]
+		if path == program.EntryPackage() || len(pkg.files) == 0 {
+			continue
+		}
+
+		filename := program.FileSet().File(pkg.files[0].Pos()).Name()
+		root, modPath := findModule(filepath.Dir(filename))
+		if root == "" || seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		rev := gitRevision(root)
+		if _, v, ok := strings.Cut(filepath.Base(root), "@"); ok && rev == "" {
+			// in the module cache
+			rev = v
+		}
+		if rev == "" {
+			rev = "unknown"
+		}
+		mods = append(mods, libraryModule{path: modPath, revision: rev})
+	}
+	return
+}
+
+// findModule returns the root directory and the path of the module
+// containing the directory.
+func findModule(dir string) (root, path string) {
+	for {
+		if b, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, modfile.ModulePath(b)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// gitRevision returns the commit hash checked out in the git repository
+// containing the directory. It returns empty string if not found.
+// A "-dirty" suffix is not added, since it requires running git.
+func gitRevision(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(gitDir); err == nil {
+			if !fi.IsDir() {
+				// worktrees and submodules: "gitdir: <path>"
+				b, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				p := strings.TrimSpace(strings.TrimPrefix(string(b), "gitdir:"))
+				if !filepath.IsAbs(p) {
+					p = filepath.Join(dir, p)
+				}
+				gitDir = p
+			}
+			return readGitHead(gitDir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func readGitHead(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+
+	head := strings.TrimSpace(string(b))
+	ref, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		// detached
+		return shortRevision(head)
+	}
+
+	// refs of worktrees are in the common directory
+	if b, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		p := strings.TrimSpace(string(b))
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(gitDir, p)
+		}
+		gitDir = p
+	}
+
+	if b, err := os.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return shortRevision(string(bytes.TrimSpace(b)))
+	}
+
+	// the ref may be packed
+	b, err = os.ReadFile(filepath.Join(gitDir, "packed-refs"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		if f := strings.Fields(line); len(f) == 2 && f[1] == ref {
+			return shortRevision(f[0])
+		}
+	}
+	return ""
+}
+
+func shortRevision(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
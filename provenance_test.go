@@ -0,0 +1,104 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestHeader(t *testing.T) {
+	conf := caseConfig(t, testdata.Cases[20])
+	setThirdPartyPackagePathPrefixes(conf.ThirdPartyPackagePathPrefixes)
+
+	program := newProgram(conf)
+	if err := parse(program, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	re := regexp.MustCompile(`^// bundled by gollect .+
+// library github.com/murosan/gollect \S+
+// inputs sha256:[0-9a-f]{64}
+$`)
+	h := header(program)
+	if !re.MatchString(h) {
+		t.Errorf("unexpected header:\n%s", h)
+	}
+	if h != header(program) {
+		t.Error("header should be stable")
+	}
+}
+
+func TestGitRevision(t *testing.T) {
+	const rev = "0123456789abcdef0123456789abcdef01234567"
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		setup func(dir string)
+		want  string
+	}{
+		{
+			name: "ref",
+			setup: func(dir string) {
+				write(filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/main\n")
+				write(filepath.Join(dir, ".git", "refs", "heads", "main"), rev+"\n")
+			},
+			want: rev[:12],
+		},
+		{
+			name: "packed",
+			setup: func(dir string) {
+				write(filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/main\n")
+				write(filepath.Join(dir, ".git", "packed-refs"), "# pack-refs with: peeled\n"+rev+" refs/heads/main\n")
+			},
+			want: rev[:12],
+		},
+		{
+			name: "detached",
+			setup: func(dir string) {
+				write(filepath.Join(dir, ".git", "HEAD"), rev+"\n")
+			},
+			want: rev[:12],
+		},
+		{
+			name: "worktree",
+			setup: func(dir string) {
+				write(filepath.Join(dir, ".git"), "gitdir: main/.git/worktrees/wt\n")
+				write(filepath.Join(dir, "main", ".git", "worktrees", "wt", "HEAD"), "ref: refs/heads/wt\n")
+				write(filepath.Join(dir, "main", ".git", "worktrees", "wt", "commondir"), "../..\n")
+				write(filepath.Join(dir, "main", ".git", "refs", "heads", "wt"), rev+"\n")
+			},
+			want: rev[:12],
+		},
+	}
+
+	for _, c := range cases {
+		dir := t.TempDir()
+		c.setup(dir)
+
+		sub := filepath.Join(dir, "lib", "pkg")
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := gitRevision(sub); actual != c.want {
+			t.Errorf("%s: want: %s, actual: %s", c.name, c.want, actual)
+		}
+	}
+}
@@ -7,49 +7,137 @@ package gollect
 import (
 	"fmt"
 	"go/ast"
-	"go/importer"
 	"go/token"
 	"go/types"
-	"strings"
 )
 
 // AnalyzeForeach executes analyzing dependency for each packages.
-func AnalyzeForeach(program *Program, initialPkg, initialObj string) {
-	fset, dset := program.FileSet(), program.DeclSet()
+// The initialObjs are names of functions or types of the initialPkg
+// treated as entry points. All methods of the types are left.
+// It returns an error if any of them is not declared.
+func AnalyzeForeach(program *Program, initialPkg string, initialObjs ...string) error {
+	pkg := analyzePackages(program, initialPkg)
+	dset := program.DeclSet()
+
+	initials := make([]Decl, len(initialObjs))
+	for i, name := range initialObjs {
+		initial, ok := dset.Get(pkg, name)
+		if !ok {
+			return fmt.Errorf("entry %q is not declared in package %s", name, initialPkg)
+		}
+		// should be set before resolving, because the type may be
+		// used from other entries.
+		if t, ok := initial.(*TypeDecl); ok {
+			t.KeepMethod()
+		}
+		initials[i] = initial
+	}
+
+	resolveFrom(program, initials)
+	return nil
+}
+
+// AnalyzeSnapshot executes analyzing dependency for each packages.
+// All exported declarations of the initialPkg are treated as entry points.
+// Exported methods of the exported types are left.
+func AnalyzeSnapshot(program *Program, initialPkg string) {
+	pkg := analyzePackages(program, initialPkg)
+
+	initials := program.DeclSet().ListExported(pkg)
+	for _, initial := range initials {
+		t, ok := initial.(*TypeDecl)
+		if !ok {
+			continue
+		}
+
+		var names []string
+		for _, m := range t.Methods() {
+			if token.IsExported(m.Name()) {
+				names = append(names, m.Name())
+			}
+		}
+		if len(names) > 0 {
+			t.KeepMethod(names...)
+		}
+	}
+
+	resolveFrom(program, initials)
+}
+
+// analyzePackages finds declarations of all packages and returns
+// the initial package.
+func analyzePackages(program *Program, initialPkg string) *Package {
+	dset := program.DeclSet()
 	iset, pset := program.ImportSet(), program.PackageSet()
 
+	cache := program.PackageCache()
+	cache.Begin()
+
+	var analyzed []*Package
 	for _, pkg := range pset {
-		ExecCheck(fset, pkg)
 		pkg.InitObjects()
-		NewDeclFinder(dset, iset, pkg).Files()
+		// minifying requires type information of all packages
+		if pkg.path == initialPkg || program.WriteOptions().Minify || !cache.Load(program, pkg) {
+			analyzed = append(analyzed, pkg)
+		}
+	}
+
+	// the cached packages are read from export data instead of
+	// type-checking, when the others import them.
+	imp := newPackageImporter(program)
+	for _, pkg := range pset {
+		if !pkg.IsCached() {
+			if err := imp.check(pkg); err != nil {
+				panic(fmt.Errorf("types.Conf check: %w", err))
+			}
+			NewDeclFinder(dset, iset, pkg).Files()
+			program.Stripper().Package(pkg)
+		}
+		program.Precomputer().Package(pkg)
+	}
+
+	cache.Link(program)
+	for _, pkg := range analyzed {
+		if pkg.path != initialPkg {
+			cache.Store(program, pkg)
+		}
 	}
 
 	pkg, ok := pset.Get(initialPkg)
 	if !ok {
 		panic("No such package: " + initialPkg)
 	}
+	return pkg
+}
+
+// resolveFrom resolves dependencies from the initials, and from
+// the declarations always left.
+func resolveFrom(program *Program, initials []Decl) {
+	dset, iset, pset := program.DeclSet(), program.ImportSet(), program.PackageSet()
 
-	initial, ok := dset.Get(pkg, initialObj)
-	if !ok {
-		panic("No such decl:" + initialObj)
-	}
 	resolver := NewDependencyResolver(dset, iset, pset)
-	resolver.CheckEach(initial)
+	for _, initial := range initials {
+		resolver.CheckEach(initial)
+	}
 
 	for _, d := range dset.ListInitOrUnderscore() {
 		resolver.CheckEach(d)
 	}
+
+	for _, d := range dset.ListKept() {
+		resolver.CheckEach(d)
+	}
 }
 
 // ExecCheck executes types.Config.Check
-func ExecCheck(fset *token.FileSet, pkg *Package) {
-	conf := &types.Config{
-		Importer: importer.ForCompiler(fset, "source", nil),
-	}
+func ExecCheck(fset *token.FileSet, imp types.Importer, pkg *Package) {
+	conf := &types.Config{Importer: imp}
 
-	if _, err := conf.Check(pkg.path, fset, pkg.files, pkg.info); err != nil {
+	tpkg, err := conf.Check(pkg.path, fset, pkg.files, pkg.info)
+	if err != nil {
 		panic(fmt.Errorf("types.Conf check: %w", err))
 	}
+	pkg.types = tpkg
 }
 
 // DeclFinder find package-level declarations and set it to DeclSet.
@@ -122,10 +210,16 @@ func (f *DeclFinder) varSpecs(decl *ast.GenDecl, isConst bool) {
 			continue
 		}
 
+		a := parseAnnotations(decl.Doc, spec.Doc)
+		if a.keepMethods || a.root || isConst && a.precompute {
+			panic(fmt.Sprintf("invalid annotation for %s", spec.Names[0].Name))
+		}
+
 		for _, id := range spec.Names {
 			name := nameForUnderscore(id)
 			d := f.dset.GetOrCreate(DecCommon, f.pkg, name)
 			d.SetNode(spec)
+			f.annotate(d, a)
 			if prev != nil {
 				if iota {
 					d.Uses(prev)
@@ -181,12 +275,14 @@ func (f *DeclFinder) typeSpecs(decl *ast.GenDecl) {
 		tdecl := f.dset.GetOrCreate(DecType, f.pkg, id.Name).(*TypeDecl)
 		tdecl.SetNode(spec)
 
-		if decl.Doc != nil {
-			for _, doc := range decl.Doc.List {
-				if strings.HasPrefix(doc.Text, keepMethods.String()) {
-					tdecl.KeepMethod()
-				}
-			}
+		a := parseAnnotations(decl.Doc, spec.Doc)
+		if a.precompute {
+			panic(fmt.Sprintf("invalid annotation for %s", id.Name))
+		}
+		f.annotate(tdecl, a)
+		if a.keepMethods {
+			// keeps all methods if no names are given
+			tdecl.KeepMethod(a.methods...)
 		}
 
 		def, ok := f.pkg.Info().Defs[id]
@@ -194,6 +290,12 @@ func (f *DeclFinder) typeSpecs(decl *ast.GenDecl) {
 			continue
 		}
 
+		if spec.Assign.IsValid() {
+			// methods are declared on the aliased type
+			tdecl.SetAlias(f.aliasKind(def))
+			continue
+		}
+
 		// fill methods
 		// https://pkg.go.dev/go/types?tab=doc#example-MethodSet
 		for _, t := range []types.Type{def.Type(), types.NewPointer(def.Type())} {
@@ -214,58 +316,147 @@ func (f *DeclFinder) typeSpecs(decl *ast.GenDecl) {
 			}
 		}
 
-		switch tpe := spec.Type.(type) {
-		case *ast.StructType:
-			for _, field := range tpe.Fields.List {
-				if id := fieldTypeID(field.Type); id != nil {
-					if it, ok := f.pkg.Info().ObjectOf(id).Type().Underlying().(*types.Interface); ok {
-						// use each interface methods.
-						// when struct type has an interface type in its field list,
-						// the interface methods should be left.
-						// example:
-						//   type S struct { sort.Interface }
-						// when the case of above example,
-						// method `Len`, `Less` ans `Swap` will be left.
-						for i := 0; i < it.NumMethods(); i++ {
-							m, ok := tdecl.GetMethodByName(it.Method(i).Name())
-							if ok {
-								tdecl.Uses(m)
-							}
-						}
-					}
-				}
+		// when a struct type has an interface type in its embedded fields,
+		// the methods overriding the interface methods should be left.
+		// example:
+		//   type S struct { sort.Interface }
+		// when the case of above example,
+		// method `Len`, `Less` ans `Swap` will be left.
+		for _, name := range promotedInterfaceMethods(def.Type()) {
+			if m, ok := tdecl.GetMethodByName(name); ok {
+				tdecl.Uses(m)
 			}
 		}
 	}
 }
 
-func fieldTypeID(expr ast.Expr) *ast.Ident {
-	switch expr := expr.(type) {
-	case *ast.Ident:
-		return expr
-	case *ast.SelectorExpr:
-		return expr.Sel
-	case *ast.IndexExpr:
-		return expr.X.(*ast.Ident)
+// aliasKind returns the kind of the alias.
+// The right hand side is compared without resolving alias chains,
+// since it is what written in the output.
+func (f *DeclFinder) aliasKind(obj types.Object) AliasKind {
+	alias, ok := obj.Type().(*types.Alias)
+	if !ok {
+		return Alias
+	}
+
+	var rhs *types.TypeName
+	var targs *types.TypeList
+	switch t := alias.Rhs().(type) {
+	case *types.Alias:
+		rhs, targs = t.Obj(), t.TypeArgs()
+	case *types.Named:
+		rhs, targs = t.Obj(), t.TypeArgs()
 	default:
+		return Alias
+	}
+
+	if rhs.Name() != obj.Name() || rhs.Pkg() == nil ||
+		rhs.Pkg().Path() == f.pkg.Path() || isBuiltinPackage(rhs.Pkg().Path()) {
+		return Alias
+	}
+
+	// type A[T any] = lib.A[T]
+	tparams := alias.TypeParams()
+	if targs.Len() != tparams.Len() {
+		return ConflictingAlias
+	}
+	for i := 0; i < targs.Len(); i++ {
+		if targs.At(i) != tparams.At(i) {
+			return ConflictingAlias
+		}
+	}
+	return SameNameAlias
+}
+
+// promotedInterfaceMethods returns names of interface methods promoted
+// through embedded fields of the struct type, including the ones of
+// interfaces embedded in embedded structs.
+func promotedInterfaceMethods(t types.Type) (names []string) {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
 		return nil
 	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Embedded() {
+			continue
+		}
+
+		// aliases are resolved to the actual types
+		ft := types.Unalias(field.Type())
+		if _, ok := ft.Underlying().(*types.Interface); !ok {
+			if _, ok := ft.(*types.Pointer); !ok {
+				// methods of pointer receivers are also promoted
+				ft = types.NewPointer(ft)
+			}
+		}
+
+		mset := types.NewMethodSet(ft)
+		for j := 0; j < mset.Len(); j++ {
+			fn := mset.At(j).Obj().(*types.Func)
+			recv := fn.Type().(*types.Signature).Recv()
+			if recv == nil || !types.IsInterface(recv.Type()) || seen[fn.Name()] {
+				continue
+			}
+			seen[fn.Name()] = true
+			names = append(names, fn.Name())
+		}
+	}
+	return
 }
 
 // FuncDecl finds package-level declarations from ast.FuncDecl.
 func (f *DeclFinder) FuncDecl(decl *ast.FuncDecl) {
 	name := decl.Name.Name
+	a := parseAnnotations(decl.Doc)
+	if a.keepMethods || a.precompute {
+		panic(fmt.Sprintf("invalid annotation for %s", name))
+	}
 
 	if decl.Recv == nil {
 		d := f.dset.GetOrCreate(DecCommon, f.pkg, name)
 		d.SetNode(decl)
+		f.annotate(d, a)
 		return
 	}
 
+	if a.root {
+		panic(fmt.Sprintf("root annotation is not available for method %s", name))
+	}
+
 	recvID := receiverID(decl.Recv.List[0].Type)
 	if recvID != nil {
 		md := f.dset.GetOrCreate(DecMethod, f.pkg, recvID.Name, name)
 		md.SetNode(decl)
+
+		// a kept method is left when its receiver type is used
+		if a.keep {
+			tdecl := f.dset.GetOrCreate(DecType, f.pkg, recvID.Name).(*TypeDecl)
+			tdecl.KeepMethod(name)
+		}
+		if a.exclude {
+			md.(*MethodDecl).Exclude()
+		}
+	}
+}
+
+// annotate applies annotations to the decl.
+func (f *DeclFinder) annotate(d Decl, a annotations) {
+	ad, ok := d.(interface {
+		Keep()
+		Exclude()
+	})
+	if !ok {
+		return
+	}
+
+	if a.keep || a.root {
+		ad.Keep()
+	}
+	if a.exclude {
+		ad.Exclude()
 	}
 }
 
@@ -303,9 +494,24 @@ func NewDependencyResolver(dset DeclSet, iset *ImportSet, pset PackageSet) *Depe
 }
 
 func (r *DependencyResolver) use(decl, usedBy Decl) {
+	// use lazily for checking embedded methods. the edge is recorded
+	// even if decl is already used from elsewhere.
+	if tdecl, ok := decl.(*TypeDecl); ok {
+		if tpe, ok := usedBy.(*TypeDecl); ok && tpe != nil {
+			tpe.Uses(tdecl)
+		}
+	}
+
 	if decl.IsUsed() {
 		return
 	}
+	if decl.IsExcluded() {
+		by := "main"
+		if usedBy != nil {
+			by = declName(usedBy)
+		}
+		panic(fmt.Errorf("%s is annotated with exclude but used from %s", declName(decl), by))
+	}
 	decl.Use()
 
 	switch decl := decl.(type) {
@@ -315,17 +521,16 @@ func (r *DependencyResolver) use(decl, usedBy Decl) {
 		r.push(decl)
 
 	case *TypeDecl:
+		if decl.Alias() == ConflictingAlias {
+			panic(fmt.Errorf("%s cannot be bundled, because it is an alias to the type of the same name with different type arguments", declName(decl)))
+		}
 		r.push(decl) // should check type earlier to resolve embedded methods.
 		decl.GetUses().Each(func(d Decl) { r.use(d, decl) })
-		if decl.ShouldKeepMethods() {
-			decl.EachMethod(func(m *MethodDecl) { r.use(m, nil) })
-		}
-
-		// use lazily for checking embedded methods
-		tpe, ok := usedBy.(*TypeDecl)
-		if ok && tpe != nil {
-			tpe.Uses(decl)
-		}
+		decl.EachMethod(func(m *MethodDecl) {
+			if decl.ShouldKeepMethod(m.Name()) && !m.IsExcluded() {
+				r.use(m, nil)
+			}
+		})
 
 	default:
 		decl.GetUses().Each(func(d Decl) { r.use(d, decl) })
@@ -355,6 +560,29 @@ func (r *DependencyResolver) CheckEach(initial Decl) {
 
 // Check checks on which given decl depending on.
 func (r *DependencyResolver) Check(decl Decl) {
+	if pkg := decl.Pkg(); pkg.IsCached() {
+		// the dependencies are restored from cache into decl's uses,
+		// so only the imports are left to be marked.
+		for _, i := range pkg.deps[decl.ID()] {
+			r.useImport(i)
+		}
+		return
+	}
+
+	r.inspect(
+		decl,
+		func(d Decl) { r.use(d, decl) },
+		func(alias, name, path string) { r.useImport(r.iset.GetOrCreate(alias, name, path)) },
+	)
+}
+
+// inspect walks the node of given decl and calls onDecl for each
+// package-level declaration it depends on, and onImport for each import.
+func (r *DependencyResolver) inspect(
+	decl Decl,
+	onDecl func(d Decl),
+	onImport func(alias, name, path string),
+) {
 	if decl.Node() == nil {
 		return
 	}
@@ -363,26 +591,13 @@ func (r *DependencyResolver) Check(decl Decl) {
 		switch node := node.(type) {
 		case *ast.SelectorExpr:
 			if sel, ok := decl.Pkg().Info().Selections[node]; ok {
-				n := named(sel.Recv())
-				if n == nil {
-					return true
-				}
-
-				path := n.Obj().Pkg().Path()
-				if isBuiltinPackage(path) {
-					return true
-				}
-
-				pkg, ok := r.pset.Get(path)
-				if !ok {
-					return true
-				}
-
-				d, ok := r.dset.Get(pkg, n.Obj().Name(), node.Sel.Name)
-				if ok {
-					r.use(d, decl)
+				// a method promoted from an embedded field is looked up
+				// on the type declaring it, not on the receiver.
+				if fn, ok := sel.Obj().(*types.Func); ok {
+					if d, ok := r.methodDecl(sel.Recv(), fn.Pkg(), fn.Name()); ok {
+						onDecl(d)
+					}
 				}
-
 				return true
 			}
 
@@ -396,8 +611,7 @@ func (r *DependencyResolver) Check(decl Decl) {
 						alias = ""
 					}
 
-					i := r.iset.GetOrCreate(alias, name, path)
-					r.useImport(i)
+					onImport(alias, name, path)
 					pkg, ok := r.pset.Get(path)
 
 					if !ok || isBuiltinPackage(path) {
@@ -406,28 +620,30 @@ func (r *DependencyResolver) Check(decl Decl) {
 
 					d, ok := r.dset.Get(pkg, node.Sel.Name)
 					if ok {
-						r.use(d, decl)
+						onDecl(d)
 					}
 				}
 			}
 
 		case *ast.Ident:
-			if _, ok := decl.Pkg().GetObject(node.Name); !ok {
-				// break when the object is
-				//   - not a package-level declaration
-				//   - an external package object
-				return true
+			if inst, ok := decl.Pkg().Info().Instances[node]; ok {
+				r.inspectInstance(decl.Pkg().Info().Uses[node], inst, onDecl)
 			}
 
 			uses, ok := decl.Pkg().Info().Uses[node]
-			if !ok || uses.Pkg() == nil || isBuiltinPackage(uses.Pkg().Path()) {
+			if !ok || uses.Pkg() == nil || uses.Pkg().Path() != decl.Pkg().Path() ||
+				uses.Parent() != uses.Pkg().Scope() {
+				// break when the object is
+				//   - not a package-level declaration
+				//   - an external package object
 				return true
 			}
 
 			switch obj := uses.(type) {
 			case *types.Const, *types.Var, *types.Func, *types.TypeName:
-				d, _ := r.dset.Get(decl.Pkg(), obj.Name())
-				r.use(d, decl)
+				if d, ok := r.dset.Get(decl.Pkg(), obj.Name()); ok {
+					onDecl(d)
+				}
 			}
 		}
 
@@ -435,6 +651,72 @@ func (r *DependencyResolver) Check(decl Decl) {
 	})
 }
 
+// inspectInstance calls onDecl for each method of the type arguments
+// required by the constraint of the type parameter, since they are
+// called through the type parameter, not through the concrete types.
+// Type arguments which are type parameters are skipped, because their
+// constraints contain the methods and are checked where they are
+// instantiated with concrete types.
+func (r *DependencyResolver) inspectInstance(obj types.Object, inst types.Instance, onDecl func(d Decl)) {
+	var tparams *types.TypeParamList
+	switch obj := obj.(type) {
+	case *types.Func:
+		tparams = obj.Type().(*types.Signature).TypeParams()
+	case *types.TypeName:
+		if n, ok := obj.Type().(*types.Named); ok {
+			tparams = n.TypeParams()
+		}
+	}
+	if tparams == nil || tparams.Len() != inst.TypeArgs.Len() {
+		return
+	}
+
+	for i := 0; i < tparams.Len(); i++ {
+		arg := inst.TypeArgs.At(i)
+		if _, ok := arg.(*types.TypeParam); ok {
+			continue
+		}
+
+		iface, ok := tparams.At(i).Constraint().Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+
+		for j := 0; j < iface.NumMethods(); j++ {
+			m := iface.Method(j)
+			if d, ok := r.methodDecl(arg, m.Pkg(), m.Name()); ok {
+				onDecl(d)
+			}
+		}
+	}
+}
+
+// methodDecl returns the MethodDecl of the method of the type.
+// Methods promoted from embedded fields are also found.
+func (r *DependencyResolver) methodDecl(t types.Type, pkg *types.Package, name string) (Decl, bool) {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return nil, false
+	}
+
+	n := named(fn.Type().(*types.Signature).Recv().Type())
+	if n == nil || n.Obj().Pkg() == nil {
+		// methods of interfaces
+		return nil, false
+	}
+
+	path := n.Obj().Pkg().Path()
+	if isBuiltinPackage(path) {
+		return nil, false
+	}
+	p, ok := r.pset.Get(path)
+	if !ok {
+		return nil, false
+	}
+	return r.dset.Get(p, n.Obj().Name(), name)
+}
+
 // CheckEmbedded checks set a method inherit from to dependency set
 // when the decl is embedded method.
 func (r *DependencyResolver) CheckEmbedded(decl Decl) {
@@ -444,9 +726,19 @@ func (r *DependencyResolver) CheckEmbedded(decl Decl) {
 	}
 
 	// dependency checking of mdecl.Type() should be done before
-	mdecl.Type().GetUses().Each(func(d Decl) {
+	seen := make(map[Decl]bool)
+	var check func(d Decl)
+	check = func(d Decl) {
 		tdecl, ok := d.(*TypeDecl)
-		if !ok {
+		if !ok || seen[tdecl] {
+			return
+		}
+		seen[tdecl] = true
+
+		if tdecl.IsAlias() {
+			// the methods are declared on the aliased type, which may
+			// be an alias as well.
+			r.aliased(tdecl, check)
 			return
 		}
 
@@ -454,11 +746,22 @@ func (r *DependencyResolver) CheckEmbedded(decl Decl) {
 		if ok {
 			r.use(m, nil)
 		}
-	})
+	}
+	mdecl.Type().GetUses().Each(check)
+}
+
+// aliased calls f for each declaration the alias refers to.
+// The alias may not be checked yet, so the node is inspected.
+func (r *DependencyResolver) aliased(alias *TypeDecl, f func(d Decl)) {
+	if alias.Pkg().IsCached() {
+		alias.GetUses().Each(f)
+		return
+	}
+	r.inspect(alias, f, func(_, _, _ string) {})
 }
 
 func named(expr types.Type) *types.Named {
-	switch expr := expr.(type) {
+	switch expr := types.Unalias(expr).(type) {
 	case *types.Named:
 		return expr
 	case *types.Pointer:
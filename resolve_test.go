@@ -0,0 +1,35 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestConflictingAlias(t *testing.T) {
+	program := NewProgram()
+	ParseAll(program, "main", []string{testdata.FilePaths.Alias})
+
+	shouldPanic(t, func() {
+		AnalyzeForeach(program, "main", "main")
+	}, "should fail because the alias conflicts with the aliased type")
+
+	d, ok := program.DeclSet().Get(program.PackageSet()["main"], "Stack")
+	if !ok || d.(*TypeDecl).Alias() != ConflictingAlias {
+		t.Errorf("should be a conflicting alias: %v", d)
+	}
+}
+
+func TestAnalyzeForeach_unknownEntry(t *testing.T) {
+	program := NewProgram()
+	ParseAll(program, "main", []string{testdata.FilePaths.Parse})
+
+	err := AnalyzeForeach(program, "main", "main", "foo")
+	if want := `entry "foo" is not declared in package main`; err == nil || err.Error() != want {
+		t.Errorf("want %q, but got %v", want, err)
+	}
+}
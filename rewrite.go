@@ -9,15 +9,16 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io"
 	"os"
-	"strings"
 
 	"github.com/fatih/color"
 	"golang.org/x/tools/go/ast/astutil"
 )
 
 var (
-	WarnOutput = os.Stderr
+	// WarnOutput is the destination of warning messages.
+	WarnOutput io.Writer = os.Stderr
 )
 
 // Filter provides a method for filtering slice of ast.Decl.
@@ -43,14 +44,16 @@ func (f *Filter) Decls(decls []ast.Decl) (res []ast.Decl) {
 		case *ast.GenDecl:
 			f.genDecl(decl)
 			if l := len(decl.Specs); l != 0 {
-				if l == 1 {
+				if l == 1 && decl.Lparen.IsValid() {
 					decl.Lparen, decl.Rparen = 0, 0 // delete '(' and ')'
+					hoistSpecDoc(decl)
 				}
 				res = append(res, decl)
 			}
 
 		case *ast.FuncDecl:
 			if f.isUsedFuncDecl(decl) {
+				removeAnnotations(decl.Doc)
 				res = append(res, decl)
 			}
 		}
@@ -58,6 +61,28 @@ func (f *Filter) Decls(decls []ast.Decl) (res []ast.Decl) {
 	return
 }
 
+// hoistSpecDoc moves the doc comment of the only spec to the decl,
+// so that it is printed before the keyword.
+func hoistSpecDoc(decl *ast.GenDecl) {
+	var doc *ast.CommentGroup
+	switch spec := decl.Specs[0].(type) {
+	case *ast.ValueSpec:
+		doc, spec.Doc = spec.Doc, nil
+	case *ast.TypeSpec:
+		doc, spec.Doc = spec.Doc, nil
+	}
+	if doc == nil || len(doc.List) == 0 {
+		return
+	}
+
+	if decl.Doc == nil {
+		decl.Doc = doc
+	} else {
+		decl.Doc = &ast.CommentGroup{List: append(decl.Doc.List, doc.List...)}
+	}
+	decl.TokPos = decl.Specs[0].Pos()
+}
+
 func (f *Filter) genDecl(node *ast.GenDecl) {
 	switch node.Tok {
 	case token.VAR, token.CONST, token.TYPE:
@@ -82,9 +107,15 @@ func (f *Filter) specs(specs []ast.Spec) (res []ast.Spec) {
 			}
 
 		case *ast.TypeSpec:
-			if f.isUsed(spec.Name.Name) {
-				res = append(res, spec)
+			d, ok := f.dset.Get(f.pkg, spec.Name.Name)
+			if !ok || !d.IsUsed() {
+				continue
+			}
+			if t, ok := d.(*TypeDecl); ok && t.Alias() == SameNameAlias {
+				// identical to the aliased type after bundling
+				continue
 			}
+			res = append(res, spec)
 		}
 	}
 	return
@@ -145,19 +176,15 @@ func (f *Filter) funcRecvKey(expr ast.Expr) string {
 }
 
 func (f *Filter) annotation(node *ast.GenDecl) {
-	if node.Doc == nil {
-		return
-	}
-	docs := make([]*ast.Comment, len(node.Doc.List))
-	i := 0
-	for _, doc := range node.Doc.List {
-		doc := doc
-		if !strings.Contains(doc.Text, annotationPrefix) {
-			docs[i] = doc
-			i++
+	removeAnnotations(node.Doc)
+	for _, spec := range node.Specs {
+		switch spec := spec.(type) {
+		case *ast.TypeSpec:
+			removeAnnotations(spec.Doc)
+		case *ast.ValueSpec:
+			removeAnnotations(spec.Doc)
 		}
 	}
-	node.Doc.List = docs[:i]
 }
 
 func (f *Filter) isUsed(id ...string) bool {
@@ -0,0 +1,360 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/build"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+type (
+	// BundleRequest is a request body of /bundle.
+	BundleRequest struct {
+		// optional. used to cancel the request by /cancel.
+		ID string `json:"id,omitempty"`
+
+		// path to main.go or glob of main package files.
+		InputFile string `json:"inputFile"`
+
+		// overrides the server's configuration if not nil.
+		ThirdPartyPackagePathPrefixes []string `json:"thirdPartyPackagePathPrefixes,omitempty"`
+	}
+
+	// BundleResponse is a response body of /bundle.
+	BundleResponse struct {
+		ID          string       `json:"id,omitempty"`
+		Source      string       `json:"source"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}
+
+	// Diagnostic is a message reported while bundling.
+	Diagnostic struct {
+		Severity string `json:"severity"` // "error" or "warning"
+		Message  string `json:"message"`
+	}
+
+	// CancelRequest is a request body of /cancel.
+	CancelRequest struct {
+		ID string `json:"id"`
+	}
+
+	// CancelResponse is a response body of /cancel.
+	CancelResponse struct {
+		Canceled bool `json:"canceled"`
+	}
+
+	// InvalidateRequest is a request body of /invalidate.
+	InvalidateRequest struct {
+		// changed files or directories. invalidates all if empty.
+		Paths []string `json:"paths"`
+	}
+
+	// InvalidateResponse is a response body of /invalidate.
+	InvalidateResponse struct {
+		Packages []string `json:"packages"`
+	}
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// Server serves bundle requests over HTTP. It keeps analyzed library
+// packages and type-checked imports in memory across requests.
+//
+// Endpoints (all of them accept POST with JSON body):
+//
+//	/bundle      BundleRequest → BundleResponse
+//	/cancel      CancelRequest → CancelResponse
+//	/invalidate  InvalidateRequest → InvalidateResponse
+//
+// A bundle request is also canceled when the client closes the connection.
+type Server struct {
+	config *Config
+
+	mu       sync.Mutex // guards followings and serializes bundling
+	cache    *PackageCache
+	importer types.Importer
+	keys     map[string]string // library package path → key at previous bundling
+
+	cmu     sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewServer returns new Server. The config is used as the base of
+// each request.
+func NewServer(config *Config) *Server {
+	cache := config.PackageCache()
+	if cache == nil {
+		cache = NewPackageCache("")
+	}
+
+	return &Server{
+		config:   config,
+		cache:    cache,
+		importer: newImporter(),
+		keys:     make(map[string]string),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+}
+
+func newImporter() types.Importer {
+	return importer.ForCompiler(token.NewFileSet(), "source", nil)
+}
+
+// Listen announces on the address. The address is "unix:<path>" for
+// Unix domain socket, otherwise host:port of TCP.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// remove the socket left by previous server
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/bundle":
+		var req BundleRequest
+		if decodeRequest(w, r, &req) {
+			respond(w, s.Bundle(r.Context(), &req))
+		}
+
+	case "/cancel":
+		var req CancelRequest
+		if decodeRequest(w, r, &req) {
+			respond(w, &CancelResponse{Canceled: s.Cancel(req.ID)})
+		}
+
+	case "/invalidate":
+		var req InvalidateRequest
+		if decodeRequest(w, r, &req) {
+			respond(w, &InvalidateResponse{Packages: s.Invalidate(req.Paths...)})
+		}
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func respond(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Bundle bundles the requested main package.
+// Errors are reported as diagnostics instead of returning.
+// A canceled request returns when the bundling stops at the next step.
+func (s *Server) Bundle(ctx context.Context, req *BundleRequest) *BundleResponse {
+	if req.ID != "" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		s.cmu.Lock()
+		s.cancels[req.ID] = cancel
+		s.cmu.Unlock()
+
+		defer func() {
+			s.cmu.Lock()
+			delete(s.cancels, req.ID)
+			s.cmu.Unlock()
+			cancel()
+		}()
+	}
+
+	res := &BundleResponse{ID: req.ID, Diagnostics: []Diagnostic{}}
+	s.bundle(ctx, req, res)
+	if err := ctx.Err(); err != nil {
+		// the result is discarded even if it is finished.
+		return &BundleResponse{
+			ID:          req.ID,
+			Diagnostics: []Diagnostic{{Severity: severityError, Message: err.Error()}},
+		}
+	}
+	return res
+}
+
+// bundle bundles the request holding the lock. The cancellation is
+// checked between the steps, and the package-level state changed by
+// bundling is restored before releasing the lock.
+func (s *Server) bundle(ctx context.Context, req *BundleRequest, res *BundleResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefixes := thirdPartyPackagePathPrefixes
+	bctx, dctx := buildContext, build.Default
+	defer func() {
+		setThirdPartyPackagePathPrefixes(prefixes)
+		buildContext, build.Default = bctx, dctx
+	}()
+
+	report := func(severity, msg string) {
+		res.Diagnostics = append(res.Diagnostics, Diagnostic{Severity: severity, Message: msg})
+	}
+	canceled := func() bool {
+		if err := ctx.Err(); err != nil {
+			report(severityError, err.Error())
+			return true
+		}
+		return false
+	}
+
+	var warn bytes.Buffer
+	WarnOutput = &warn
+	defer func() {
+		WarnOutput = os.Stderr
+		for _, line := range strings.Split(strings.TrimSpace(warn.String()), "\n") {
+			if line != "" {
+				report(severityWarning, strings.TrimPrefix(line, "[warn] "))
+			}
+		}
+	}()
+
+	defer func() {
+		if err := recover(); err != nil {
+			res.Source = ""
+			report(severityError, fmt.Sprint(err))
+		}
+	}()
+
+	if canceled() {
+		return
+	}
+
+	config := *s.config
+	if req.InputFile != "" {
+		config.InputFile = req.InputFile
+	}
+	if req.ThirdPartyPackagePathPrefixes != nil {
+		config.ThirdPartyPackagePathPrefixes = req.ThirdPartyPackagePathPrefixes
+	}
+	if err := config.validateInput(); err != nil {
+		report(severityError, err.Error())
+		return
+	}
+
+	setThirdPartyPackagePathPrefixes(config.ThirdPartyPackagePathPrefixes)
+	setBuildContext(config.GOOS, config.GOARCH)
+
+	p := newProgram(&config)
+	p.SetPackageCache(s.cache)
+
+	if err := parse(p, &config); err != nil {
+		report(severityError, err.Error())
+		return
+	}
+	if canceled() {
+		return
+	}
+
+	s.refresh(p)
+	p.SetImporter(s.importer)
+
+	if err := analyze(p, &config); err != nil {
+		report(severityError, err.Error())
+		return
+	}
+	if canceled() {
+		return
+	}
+	if err := precomputeProgram(p, &config); err != nil {
+		report(severityError, err.Error())
+		return
+	}
+	if canceled() {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, p); err != nil {
+		report(severityError, err.Error())
+		return
+	}
+	res.Source = buf.String()
+}
+
+// refresh discards the type-checked imports if any library package
+// has been changed since previous bundling.
+func (s *Server) refresh(p *Program) {
+	s.cache.Begin()
+
+	changed := false
+	for path, pkg := range p.PackageSet() {
+		if path == p.EntryPackage() {
+			continue
+		}
+
+		key, err := s.cache.key(p, pkg)
+		if prev, ok := s.keys[path]; err != nil || (ok && prev != key) {
+			changed = true
+		}
+		s.keys[path] = key
+	}
+
+	if changed {
+		s.importer = newImporter()
+	}
+}
+
+// Cancel cancels the bundle request of the id.
+// It returns false if there is no such request running.
+func (s *Server) Cancel(id string) bool {
+	s.cmu.Lock()
+	defer s.cmu.Unlock()
+
+	cancel, ok := s.cancels[id]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Invalidate forgets the packages that contain any of given paths.
+// If no path is given, all packages are forgotten.
+// It returns invalidated package paths.
+func (s *Server) Invalidate(paths ...string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.importer = newImporter()
+	s.keys = make(map[string]string)
+
+	invalidated := s.cache.Invalidate(paths...)
+	if invalidated == nil {
+		invalidated = []string{}
+	}
+	return invalidated
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/build"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestServer(t *testing.T) {
+	config := DefaultConfig()
+	config.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	config.CacheDir = t.TempDir()
+
+	ts := httptest.NewServer(NewServer(config))
+	defer ts.Close()
+
+	post := func(t *testing.T, path string, req, res interface{}) {
+		t.Helper()
+		b, _ := json.Marshal(req)
+		r, err := http.Post(ts.URL+path, "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+		if r.StatusCode != http.StatusOK {
+			t.Fatalf("status: %d", r.StatusCode)
+		}
+		if err := json.NewDecoder(r.Body).Decode(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// bundle twice to check the warm state works as well as the cold one.
+	for n := 0; n < 2; n++ {
+		for i, tc := range testdata.Cases {
+			if tc.Config != "" {
+				// the server is not configured for each case
+				continue
+			}
+
+			var res BundleResponse
+			post(t, "/bundle", &BundleRequest{InputFile: tc.Input}, &res)
+
+			for _, d := range res.Diagnostics {
+				if d.Severity == severityError {
+					t.Fatalf("At: %d, %s", i, d.Message)
+				}
+			}
+
+			expected, err := os.ReadFile(tc.Expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(expected) != res.Source {
+				diff := dmp.New().DiffMain(string(expected), res.Source, true)
+				t.Errorf("\n[at] %d-%d\n[diff]\n%s", n, i, colorDiff(diff))
+			}
+		}
+	}
+
+	var inv InvalidateResponse
+	post(t, "/invalidate", &InvalidateRequest{Paths: []string{testdata.Cases[6].Input}}, &inv)
+	if len(inv.Packages) != 0 {
+		// main package's files are not memorized
+		t.Errorf("invalidated: %v", inv.Packages)
+	}
+
+	post(t, "/invalidate", &InvalidateRequest{}, &inv)
+	if len(inv.Packages) == 0 {
+		t.Error("want invalidated packages but got nothing")
+	}
+
+	var cancel CancelResponse
+	post(t, "/cancel", &CancelRequest{ID: "unknown"}, &cancel)
+	if cancel.Canceled {
+		t.Error("unknown request should not be canceled")
+	}
+
+	r, err := http.Get(ts.URL + "/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("status: %d", r.StatusCode)
+	}
+}
+
+func TestServer_BundleCanceled(t *testing.T) {
+	config := DefaultConfig()
+	config.NoCache = true
+	server := NewServer(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := server.Bundle(ctx, &BundleRequest{ID: "a", InputFile: testdata.Cases[0].Input})
+	if res.Source != "" || len(res.Diagnostics) != 1 || res.Diagnostics[0].Severity != severityError {
+		t.Errorf("want canceled, got %+v", res)
+	}
+
+	// nothing is left running after the response
+	if !server.mu.TryLock() {
+		t.Fatal("the lock should be released")
+	}
+	server.mu.Unlock()
+}
+
+func TestServer_BundleRestoresState(t *testing.T) {
+	setThirdPartyPackagePathPrefixes([]string{"golang.org/x/exp"})
+	prefixes, goos := thirdPartyPackagePathPrefixes, buildContext.GOOS
+
+	config := DefaultConfig()
+	config.NoCache = true
+	config.GOOS = "plan9"
+	server := NewServer(config)
+
+	res := server.Bundle(context.Background(), &BundleRequest{
+		InputFile:                     testdata.Cases[0].Input,
+		ThirdPartyPackagePathPrefixes: []string{"example.com/"},
+	})
+	if res.Source == "" {
+		t.Fatalf("want source, got %+v", res)
+	}
+
+	if !slices.Equal(thirdPartyPackagePathPrefixes, prefixes) {
+		t.Errorf("prefixes: want %v, got %v", prefixes, thirdPartyPackagePathPrefixes)
+	}
+	if buildContext.GOOS != goos || build.Default.GOOS != goos {
+		t.Errorf("goos: want %s, got %s and %s", goos, buildContext.GOOS, build.Default.GOOS)
+	}
+	if WarnOutput != os.Stderr {
+		t.Error("WarnOutput should be restored")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// SizeEntry is the byte contribution of a declaration or a package.
+type SizeEntry struct {
+	Name  string
+	Bytes int
+}
+
+// SizeReport reports the size of the output and contributions of
+// the declarations left.
+// The contributions are source sizes measured with the positions of
+// declarations in source files. They do not add up to the size of
+// the output when it is minified or comments are dropped.
+type SizeReport struct {
+	Total    int // size of the output
+	Limit    int // 0 means unlimited
+	Packages []SizeEntry
+	Decls    []SizeEntry
+}
+
+// NewSizeReport returns new SizeReport. The entries are sorted by
+// bytes in descending order.
+func NewSizeReport(program *Program, total, limit int) *SizeReport {
+	r := &SizeReport{Total: total, Limit: limit}
+	pkgs := make(map[string]int)
+
+	for decl, n := range declSizes(program) {
+		r.Decls = append(r.Decls, SizeEntry{Name: declName(decl), Bytes: n})
+		pkgs[decl.Pkg().Path()] += n
+	}
+
+	for path, n := range pkgs {
+		r.Packages = append(r.Packages, SizeEntry{Name: path, Bytes: n})
+	}
+
+	sortSizeEntries(r.Packages)
+	sortSizeEntries(r.Decls)
+	return r
+}
+
+// declSizes returns bytes of the declarations used.
+func declSizes(program *Program) map[Decl]int {
+	fset := program.FileSet()
+
+	// a spec declared alone is measured with its keyword and doc comment
+	single := make(map[ast.Node]*ast.GenDecl)
+	for _, pkg := range program.PackageSet() {
+		for _, file := range pkg.files {
+			for _, d := range file.Decls {
+				if d, ok := d.(*ast.GenDecl); ok && len(d.Specs) == 1 && !d.Lparen.IsValid() {
+					single[d.Specs[0]] = d
+				}
+			}
+		}
+	}
+
+	// a spec declaring several names is shared by the declarations
+	shared := make(map[ast.Node][]Decl)
+	program.DeclSet().Each(func(decl Decl) {
+		if decl.IsUsed() {
+			shared[decl.Node()] = append(shared[decl.Node()], decl)
+		}
+	})
+
+	sizes := make(map[Decl]int)
+	for node, decls := range shared {
+		if d, ok := single[node]; ok {
+			node = d
+		}
+
+		n := declSize(fset, node)
+		if n == 0 {
+			continue
+		}
+
+		// split the bytes, so that the sum does not exceed the output.
+		// the remainder goes to the first one.
+		sort.Slice(decls, func(i, j int) bool { return decls[i].ID() < decls[j].ID() })
+		for i, decl := range decls {
+			sizes[decl] = n / len(decls)
+			if i == 0 {
+				sizes[decl] += n % len(decls)
+			}
+		}
+	}
+	return sizes
+}
+
+// declSize returns bytes of the node including its doc comment.
+func declSize(fset *token.FileSet, node ast.Node) int {
+	if node == nil {
+		return 0
+	}
+
+	var doc *ast.CommentGroup
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		doc = node.Doc
+	case *ast.GenDecl:
+		doc = node.Doc
+	case *ast.ValueSpec:
+		doc = node.Doc
+	case *ast.TypeSpec:
+		doc = node.Doc
+	}
+
+	start := node.Pos()
+	if doc != nil && len(doc.List) > 0 {
+		// the list is empty if all comments are annotations
+		start = doc.Pos()
+	}
+
+	if !start.IsValid() || !node.End().IsValid() {
+		return 0
+	}
+
+	end := fset.Position(node.End()).Offset
+	if lit := lastLiteral(node); lit != nil {
+		// the literal may be longer than the source if it is precomputed
+		end = fset.Position(lit.Pos()).Offset + len(lit.Value)
+	}
+	return end - fset.Position(start).Offset
+}
+
+// lastLiteral returns the literal at the end of the var declaration.
+func lastLiteral(node ast.Node) *ast.BasicLit {
+	if d, ok := node.(*ast.GenDecl); ok && !d.Rparen.IsValid() && len(d.Specs) == 1 {
+		node = d.Specs[0]
+	}
+	spec, ok := node.(*ast.ValueSpec)
+	if !ok || len(spec.Values) == 0 {
+		return nil
+	}
+	lit, _ := spec.Values[len(spec.Values)-1].(*ast.BasicLit)
+	return lit
+}
+
+func sortSizeEntries(a []SizeEntry) {
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Bytes != a[j].Bytes {
+			return a[i].Bytes > a[j].Bytes
+		}
+		return a[i].Name < a[j].Name
+	})
+}
+
+// Exceeded returns true if the output is larger than the limit.
+func (r *SizeReport) Exceeded() bool {
+	return r.Limit > 0 && r.Total > r.Limit
+}
+
+// WriteTo writes the report as tables.
+func (r *SizeReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	if r.Limit > 0 {
+		fmt.Fprintf(&b, "size: %d bytes (limit: %d bytes)\n", r.Total, r.Limit)
+	} else {
+		fmt.Fprintf(&b, "size: %d bytes\n", r.Total)
+	}
+	b.WriteString("bytes of packages and declarations are source sizes, before minify and dropping comments\n")
+
+	table := func(header string, entries []SizeEntry) {
+		b.WriteString("\n")
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintf(tw, "source bytes\t  %s\n", header)
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%d\t  %s\n", e.Bytes, e.Name)
+		}
+		tw.Flush()
+	}
+	table("package", r.Packages)
+	table("declaration", r.Decls)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestSizeReport(t *testing.T) {
+	tc := testdata.Cases[18]
+	conf := caseConfig(t, tc)
+	conf.Snapshot, conf.PackageName = "", ""
+	setThirdPartyPackagePathPrefixes(conf.ThirdPartyPackagePathPrefixes)
+
+	program := newProgram(conf)
+	if err := parse(program, conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := analyze(program, conf); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, program); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewSizeReport(program, buf.Len(), 100)
+	if !r.Exceeded() {
+		t.Errorf("should be exceeded. total=%d", r.Total)
+	}
+
+	const lib = "github.com/murosan/gollect/testdata/cases/18/input/lib"
+	want := []string{
+		lib + ".Dx",
+		lib + ".Dy",
+		lib + ".Max",
+		lib + ".NewStack",
+		lib + ".Stack",
+		lib + ".Stack.Pop",
+		lib + ".Stack.Push",
+		lib + ".Stack.top",
+		"github.com/murosan/gollect/testdata/cases/18/input/util.Less",
+		"main.main",
+	}
+	var names []string
+	bytesOf := make(map[string]int)
+	for _, e := range r.Decls {
+		names = append(names, e.Name)
+		bytesOf[e.Name] = e.Bytes
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("\n[want]\n%v\n[actual]\n%v", want, names)
+	}
+
+	// the spec declaring both is counted once
+	spec := "// Dx and Dy are the moves to four neighbors.\nvar Dx, Dy = []int{0, 1, 0, -1}, []int{1, 0, -1, 0}"
+	if n := bytesOf[lib+".Dx"] + bytesOf[lib+".Dy"]; n != len(spec) {
+		t.Errorf("Dx and Dy: want %d bytes in total, but got %d", len(spec), n)
+	}
+
+	for _, entries := range [][]SizeEntry{r.Packages, r.Decls} {
+		if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Bytes > entries[j].Bytes }) {
+			t.Errorf("should be sorted in descending order: %v", entries)
+		}
+	}
+
+	sum := func(entries []SizeEntry) (n int) {
+		for _, e := range entries {
+			n += e.Bytes
+		}
+		return
+	}
+	if sum(r.Packages) != sum(r.Decls) || sum(r.Decls) > r.Total {
+		t.Errorf("packages=%d, decls=%d, total=%d", sum(r.Packages), sum(r.Decls), r.Total)
+	}
+
+	var out strings.Builder
+	if _, err := r.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "source bytes") {
+		t.Errorf("should be labeled as source sizes:\n%s", out.String())
+	}
+
+	if r := NewSizeReport(program, buf.Len(), 0); r.Exceeded() {
+		t.Error("should not be exceeded without limit")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// solutionDirectives is a set of directives parsed from the files of
+// the main package.
+type solutionDirectives struct {
+	out   []string
+	allow []string
+}
+
+// parseSolutionDirectives parses the directives in the files.
+// Unknown directives and invalid values are errors.
+func parseSolutionDirectives(fset *token.FileSet, files []*ast.File) (d solutionDirectives, err error) {
+	for _, f := range files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if !isSolutionDirective(c) {
+					continue
+				}
+
+				pos := fset.Position(c.Pos())
+				name, args, _ := strings.Cut(c.Text, " ")
+				var values []string
+				for _, v := range strings.Split(args, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						values = append(values, v)
+					}
+				}
+				if len(values) == 0 {
+					return d, fmt.Errorf("%s: %s needs arguments", pos, name)
+				}
+
+				switch Directive(name) {
+				case outDirective:
+					for _, out := range values {
+						if err := validateOutputPath(out); err != nil {
+							return d, fmt.Errorf("%s: %w", pos, err)
+						}
+					}
+					d.out = append(d.out, values...)
+
+				case allowDirective:
+					for _, prefix := range values {
+						if err := module.CheckImportPath(prefix); err != nil {
+							return d, fmt.Errorf("%s: invalid package path prefix: %w", pos, err)
+						}
+					}
+					d.allow = append(d.allow, values...)
+
+				default:
+					return d, fmt.Errorf("%s: unknown directive %s", pos, name)
+				}
+			}
+		}
+	}
+	return d, nil
+}
+
+// apply merges the directives on top of the config.
+// It returns true if the package path prefixes are changed.
+func (d solutionDirectives) apply(config *Config) (changed bool) {
+	if len(d.out) > 0 {
+		config.OutputPaths = d.out
+	}
+	for _, prefix := range d.allow {
+		if !slices.Contains(config.ThirdPartyPackagePathPrefixes, prefix) {
+			// clipped, not to modify the slice shared with other configs
+			config.ThirdPartyPackagePathPrefixes = append(slices.Clip(config.ThirdPartyPackagePathPrefixes), prefix)
+			changed = true
+		}
+	}
+	return
+}
+
+// isSolutionDirective returns true if the comment is a directive of
+// the solution, such as //gollect:out.
+func isSolutionDirective(c *ast.Comment) bool {
+	return strings.HasPrefix(c.Text, directivePrefix)
+}
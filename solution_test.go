@@ -0,0 +1,116 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSolutionDirectives(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "c.go")
+
+	cases := []struct {
+		src  string
+		want solutionDirectives
+		err  string
+	}{
+		{
+			src: `//gollect:out stdout,` + out + `
+//gollect:allow gonum.org/v1/gonum
+//gollect:allow golang.org/x/exp, github.com/emirpasic/gods
+
+package main
+
+func main() {}
+`,
+			want: solutionDirectives{
+				out:   []string{"stdout", out},
+				allow: []string{"gonum.org/v1/gonum", "golang.org/x/exp", "github.com/emirpasic/gods"},
+			},
+		},
+		{
+			src: "package main\n\n// gollect: keep\nfunc main() {}\n",
+		},
+		{
+			src: "//gollect:output stdout\n\npackage main\n",
+			err: "main.go:1:1: unknown directive //gollect:output",
+		},
+		{
+			src: "package main\n\n//gollect:out\n",
+			err: "main.go:3:1: //gollect:out needs arguments",
+		},
+		{
+			src: "//gollect:out " + filepath.Join(file, "c.go") + "\npackage main\n",
+			err: "cannot write output",
+		},
+		{
+			src: "//gollect:allow gonum.org/v1/gonum/\npackage main\n",
+			err: "invalid package path prefix",
+		},
+	}
+
+	for i, c := range cases {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "main.go", c.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := parseSolutionDirectives(fset, []*ast.File{f})
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("at:%d want error containing %q, but got %v", i, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("at:%d %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(d, c.want) {
+			t.Errorf("at:%d\n[want]\n%v\n[actual]\n%v", i, c.want, d)
+		}
+	}
+}
+
+func TestSolutionDirectives_apply(t *testing.T) {
+	prefixes := make([]string, 1, 4)
+	prefixes[0] = "golang.org/x/exp"
+	config := &Config{OutputPaths: []string{"stdout"}, ThirdPartyPackagePathPrefixes: prefixes}
+
+	d := solutionDirectives{
+		out:   []string{"clipboard"},
+		allow: []string{"golang.org/x/exp", "gonum.org/v1/gonum"},
+	}
+	if !d.apply(config) {
+		t.Error("want prefixes changed")
+	}
+
+	if want := []string{"clipboard"}; !reflect.DeepEqual(config.OutputPaths, want) {
+		t.Errorf("want %v, but got %v", want, config.OutputPaths)
+	}
+	if want := []string{"golang.org/x/exp", "gonum.org/v1/gonum"}; !reflect.DeepEqual(config.ThirdPartyPackagePathPrefixes, want) {
+		t.Errorf("want %v, but got %v", want, config.ThirdPartyPackagePathPrefixes)
+	}
+	if prefixes[:2][1] != "" {
+		t.Error("the original slice is modified")
+	}
+
+	if d.apply(config) {
+		t.Error("want prefixes not changed when applied twice")
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type (
+	// StatsReport is usage statistics of library declarations among
+	// solutions in a directory tree.
+	StatsReport struct {
+		// directories of the solutions found. the ones failed to be
+		// analyzed are also listed in Errors.
+		Solutions []string `json:"solutions"`
+
+		// declarations used by at least one solution, sorted by the
+		// number of solutions in descending order.
+		Decls []DeclStats `json:"decls"`
+
+		// declarations of the library packages never used, including
+		// the packages imported by no solution.
+		Unused []string `json:"unused"`
+
+		// library packages sorted by transitive bytes in descending order.
+		Packages []PackageStats `json:"packages"`
+
+		// solutions and library packages failed to be analyzed.
+		Errors []StatsError `json:"errors"`
+	}
+
+	// StatsOptions configures Stats.
+	StatsOptions struct {
+		// package patterns of the libraries, such as ./lib/... or
+		// github.com/owner/repo/lib/... . All packages of the modules
+		// providing the library packages imported by the solutions are
+		// used if empty.
+		Libraries []string
+	}
+
+	// StatsError is a failure of analyzing a solution or a library
+	// package.
+	StatsError struct {
+		Path  string `json:"path"` // directory of solution or package path
+		Error string `json:"error"`
+	}
+
+	// DeclStats is usage of a declaration.
+	DeclStats struct {
+		Name  string `json:"name"`
+		Uses  int    `json:"uses"`  // number of solutions
+		Bytes int    `json:"bytes"` // size of the declaration
+	}
+
+	// PackageStats is usage of a library package. The bytes are summed
+	// over solutions.
+	PackageStats struct {
+		Path       string `json:"path"`
+		Uses       int    `json:"uses"`       // number of solutions
+		Bytes      int    `json:"bytes"`      // declarations of the package
+		Transitive int    `json:"transitive"` // including its dependencies
+	}
+)
+
+// Stats analyzes dependencies of every main package under the root
+// directory and aggregates usage of library declarations.
+// The config is used for each of them, except for the input file.
+// Failures of solutions are reported in the report instead of aborting.
+func Stats(config *Config, root string, opts StatsOptions) (*StatsReport, error) {
+	setBuildContext(config.GOOS, config.GOARCH)
+
+	dirs, err := findSolutions(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// not null in JSON
+	r := &StatsReport{
+		Solutions: append([]string{}, dirs...),
+		Decls:     []DeclStats{},
+		Unused:    []string{},
+		Packages:  []PackageStats{},
+		Errors:    []StatsError{},
+	}
+	decls := make(map[string]*DeclStats)
+	pkgs := make(map[string]*PackageStats)
+	imported := make(map[string]bool)
+
+	for _, dir := range dirs {
+		c := *config
+		c.InputFile = filepath.Join(dir, "*.go")
+
+		// reset, since //gollect:allow of the previous solution
+		// changes the prefixes.
+		setThirdPartyPackagePathPrefixes(c.ThirdPartyPackagePathPrefixes)
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+
+		p, err := analyzeSolution(&c)
+		if err != nil {
+			r.Errors = append(r.Errors, StatsError{Path: dir, Error: err.Error()})
+			continue
+		}
+		collectStats(p, decls, pkgs)
+		for path := range p.PackageSet() {
+			if path != p.EntryPackage() {
+				imported[path] = true
+			}
+		}
+	}
+
+	// the packages imported by no solution are unused entirely
+	setThirdPartyPackagePathPrefixes(config.ThirdPartyPackagePathPrefixes)
+	libs, err := libraryPackages(opts.Libraries, sortedKeys(imported))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range libs {
+		if imported[path] {
+			continue
+		}
+
+		names, err := libraryDecls(config, path)
+		if err != nil {
+			r.Errors = append(r.Errors, StatsError{Path: path, Error: err.Error()})
+			continue
+		}
+		for _, name := range names {
+			if _, ok := decls[name]; !ok {
+				decls[name] = &DeclStats{Name: name}
+			}
+		}
+	}
+
+	for name, d := range decls {
+		if d.Uses == 0 {
+			r.Unused = append(r.Unused, name)
+		} else {
+			r.Decls = append(r.Decls, *d)
+		}
+	}
+	for _, p := range pkgs {
+		r.Packages = append(r.Packages, *p)
+	}
+
+	sort.Strings(r.Unused)
+	sort.Slice(r.Decls, func(i, j int) bool {
+		if r.Decls[i].Uses != r.Decls[j].Uses {
+			return r.Decls[i].Uses > r.Decls[j].Uses
+		}
+		return r.Decls[i].Name < r.Decls[j].Name
+	})
+	sort.Slice(r.Packages, func(i, j int) bool {
+		if r.Packages[i].Transitive != r.Packages[j].Transitive {
+			return r.Packages[i].Transitive > r.Packages[j].Transitive
+		}
+		return r.Packages[i].Path < r.Packages[j].Path
+	})
+	return r, nil
+}
+
+// findSolutions returns directories of main packages under the root.
+// Directories ignored by go command such as testdata are skipped.
+func findSolutions(root string) (dirs []string, err error) {
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		name := d.Name()
+		if path != root &&
+			(strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "testdata" || name == "vendor") {
+			return filepath.SkipDir
+		}
+
+		pkg, err := buildContext.ImportDir(path, 0)
+		if err != nil {
+			var noGo *build.NoGoError
+			if errors.As(err, &noGo) {
+				return nil
+			}
+			return err
+		}
+		if pkg.Name == "main" {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return
+}
+
+// analyzeSolution parses and analyzes the main package.
+// Panics while analyzing are returned as errors.
+func analyzeSolution(config *Config) (p *Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	p = newProgram(config)
+	if err := parse(p, config); err != nil {
+		return nil, err
+	}
+	if err := analyze(p, config); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// libraryPackages returns the paths of library packages matched by
+// the patterns. If no pattern is given, the packages of the modules
+// providing the imported packages are returned.
+func libraryPackages(patterns, imported []string) ([]string, error) {
+	load := func(mode packages.LoadMode, patterns []string) ([]*packages.Package, error) {
+		cfg := &packages.Config{Mode: mode, Env: buildEnv(), BuildFlags: buildFlags()}
+		pkgs, err := packages.Load(cfg, patterns...)
+		if err != nil {
+			return nil, fmt.Errorf("load libraries: %w", err)
+		}
+		return pkgs, nil
+	}
+
+	if len(patterns) == 0 {
+		if len(imported) == 0 {
+			return nil, nil
+		}
+		pkgs, err := load(packages.NeedName|packages.NeedModule, imported)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, pkg := range pkgs {
+			if m := pkg.Module; m != nil && !seen[m.Path] {
+				seen[m.Path] = true
+				patterns = append(patterns, m.Path+"/...")
+			}
+		}
+		if len(patterns) == 0 {
+			return nil, nil
+		}
+	}
+
+	pkgs, err := load(packages.NeedName, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, pkg := range pkgs {
+		if pkg.Name != "" && pkg.Name != "main" && !isBuiltinPackage(pkg.PkgPath) {
+			paths = append(paths, pkg.PkgPath)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// libraryDecls returns the names of declarations of the package.
+// Panics while analyzing are returned as errors.
+func libraryDecls(config *Config, path string) (names []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	p := newProgram(config)
+	ParseAll(p, path, p.PackageCache().FilePaths(path))
+	analyzePackages(p, path)
+
+	p.DeclSet().Each(func(decl Decl) {
+		if decl.Pkg().Path() != path {
+			return
+		}
+		if d, ok := decl.(*CommonDecl); ok && d.IsInitOrUnderScore() {
+			return
+		}
+		names = append(names, declName(decl))
+	})
+	return names, nil
+}
+
+// collectStats adds usage of library declarations of the program.
+func collectStats(p *Program, decls map[string]*DeclStats, pkgs map[string]*PackageStats) {
+	sizes := declSizes(p)
+	entry := p.EntryPackage()
+	resolver := NewDependencyResolver(p.DeclSet(), nil, p.PackageSet())
+
+	used := make(map[string][]Decl)
+	p.DeclSet().Each(func(decl Decl) {
+		path := decl.Pkg().Path()
+		if path == entry {
+			return
+		}
+		if d, ok := decl.(*CommonDecl); ok && d.IsInitOrUnderScore() {
+			// their names are not stable
+			return
+		}
+
+		name := declName(decl)
+		d, ok := decls[name]
+		if !ok {
+			d = &DeclStats{Name: name}
+			decls[name] = d
+		}
+		if !decl.IsUsed() {
+			return
+		}
+
+		d.Uses++
+		d.Bytes = sizes[decl]
+		used[path] = append(used[path], decl)
+	})
+
+	for path, a := range used {
+		s, ok := pkgs[path]
+		if !ok {
+			s = &PackageStats{Path: path}
+			pkgs[path] = s
+		}
+		s.Uses++
+
+		seen := make(map[Decl]bool)
+		for _, decl := range a {
+			s.Bytes += sizes[decl]
+			reachDecls(resolver, decl, seen)
+		}
+		for decl := range seen {
+			s.Transitive += sizes[decl]
+		}
+	}
+}
+
+// reachDecls marks the declaration and used declarations it depends on.
+func reachDecls(r *DependencyResolver, decl Decl, seen map[Decl]bool) {
+	if seen[decl] || !decl.IsUsed() {
+		return
+	}
+	seen[decl] = true
+
+	next := func(d Decl) { reachDecls(r, d, seen) }
+
+	// uses of cached declarations are restored from cache
+	decl.GetUses().Each(next)
+	if !decl.Pkg().IsCached() {
+		r.inspect(decl, next, func(_, _, _ string) {})
+	}
+
+	switch decl := decl.(type) {
+	case *TypeDecl:
+		for _, m := range decl.Methods() {
+			next(m)
+		}
+	case *MethodDecl:
+		next(decl.Type())
+	}
+}
+
+// WriteTo writes the report as tables.
+func (r *StatsReport) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "solutions: %d\n", len(r.Solutions))
+
+	b.WriteString("\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "uses\tbytes\ttransitive\t  package\n")
+	for _, p := range r.Packages {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t  %s\n", p.Uses, p.Bytes, p.Transitive, p.Path)
+	}
+	tw.Flush()
+
+	b.WriteString("\n")
+	tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "uses\tbytes\t  declaration\n")
+	for _, d := range r.Decls {
+		fmt.Fprintf(tw, "%d\t%d\t  %s\n", d.Uses, d.Bytes, d.Name)
+	}
+	tw.Flush()
+
+	if len(r.Unused) > 0 {
+		b.WriteString("\nunused:\n")
+		for _, name := range r.Unused {
+			fmt.Fprintf(&b, "  %s\n", name)
+		}
+	}
+
+	if len(r.Errors) > 0 {
+		b.WriteString("\nerrors:\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  %s: %s\n", e.Path, e.Error)
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+const statsLibs = "./testdata/codes/stats/lib/..."
+
+func TestStats(t *testing.T) {
+	conf := DefaultConfig()
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+
+	opts := StatsOptions{Libraries: []string{statsLibs}}
+	r, err := Stats(conf, testdata.FilePaths.Stats, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Errors) != 0 {
+		t.Errorf("errors: %+v", r.Errors)
+	}
+
+	root := testdata.FilePaths.Stats
+	if want := []string{filepath.Join(root, "a"), filepath.Join(root, "b")}; strings.Join(r.Solutions, ",") != strings.Join(want, ",") {
+		t.Errorf("solutions: want %v, actual %v", want, r.Solutions)
+	}
+
+	const (
+		mathx = "github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+		ds    = "github.com/murosan/gollect/testdata/codes/stats/lib/ds"
+		graph = "github.com/murosan/gollect/testdata/codes/stats/lib/graph"
+	)
+
+	uses := map[string]int{
+		mathx + ".Max":     2,
+		ds + ".Stack":      1,
+		ds + ".Stack.Push": 1,
+		mathx + ".Min":     1,
+	}
+	var order []string
+	for _, d := range r.Decls {
+		order = append(order, d.Name)
+		if d.Uses != uses[d.Name] {
+			t.Errorf("%s: want %d uses, actual %d", d.Name, uses[d.Name], d.Uses)
+		}
+		if d.Bytes == 0 {
+			t.Errorf("%s: bytes should be measured", d.Name)
+		}
+	}
+	if want := []string{mathx + ".Max", ds + ".Stack", ds + ".Stack.Push", mathx + ".Min"}; strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("decls: want %v, actual %v", want, order)
+	}
+
+	// graph is imported by no solution
+	want := []string{ds + ".Stack.Pop", graph + ".Graph", graph + ".Graph.AddEdge", graph + ".MaxDegree", mathx + ".Abs"}
+	if strings.Join(r.Unused, ",") != strings.Join(want, ",") {
+		t.Errorf("unused: want %v, actual %v", want, r.Unused)
+	}
+
+	pkgs := make(map[string]PackageStats)
+	for _, p := range r.Packages {
+		pkgs[p.Path] = p
+	}
+	if p := pkgs[mathx]; p.Uses != 2 || p.Bytes != p.Transitive {
+		t.Errorf("mathx: %+v", p)
+	}
+	// ds depends on mathx.Max
+	if p := pkgs[ds]; p.Uses != 1 || p.Bytes >= p.Transitive {
+		t.Errorf("ds: %+v", p)
+	}
+
+	// the second run restores packages from cache
+	cached, err := Stats(conf, testdata.FilePaths.Stats, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, cached) {
+		t.Errorf("should be same with cache\n[want]\n%+v\n[actual]\n%+v", r, cached)
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"solutions: 2", mathx + ".Max", "unused:", mathx + ".Abs"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("table should contain %q\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestStats_allowDirective(t *testing.T) {
+	conf := DefaultConfig()
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+
+	// only the solution a allows ds, so ds is used from b
+	r, err := Stats(conf, testdata.FilePaths.StatsAllow, StatsOptions{Libraries: []string{statsLibs}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ds = "github.com/murosan/gollect/testdata/codes/stats/lib/ds"
+	uses := make(map[string]int)
+	for _, d := range r.Decls {
+		uses[d.Name] = d.Uses
+	}
+	if uses[ds+".Stack"] != 1 || uses[ds+".Stack.Push"] != 1 {
+		t.Errorf("want 1 use of ds.Stack and ds.Stack.Push, but got %v", uses)
+	}
+}
+
+func TestStats_errors(t *testing.T) {
+	conf := DefaultConfig()
+	conf.ThirdPartyPackagePathPrefixes = []string{"golang.org/x/exp"}
+	conf.CacheDir = t.TempDir()
+
+	// b imports a package which does not exist
+	r, err := Stats(conf, testdata.FilePaths.StatsError, StatsOptions{Libraries: []string{statsLibs}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := testdata.FilePaths.StatsError
+	if len(r.Errors) != 1 || r.Errors[0].Path != filepath.Join(root, "b") {
+		t.Fatalf("want an error of b, but got %+v", r.Errors)
+	}
+
+	const mathx = "github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+	if len(r.Decls) != 1 || r.Decls[0].Name != mathx+".Max" || r.Decls[0].Uses != 1 {
+		t.Errorf("a should be analyzed: %+v", r.Decls)
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "errors:") {
+		t.Errorf("table should contain errors\n%s", buf.String())
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"slices"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Stripper removes debug-only statements before resolving dependencies,
+// so the declarations used only from them are removed as unused.
+//
+//	dbg(x)              // removed when `dbg` is listed in calls
+//	if debug { ... }    // removed when `debug` is listed in consts
+//	if debug { A } else { B } → B
+//
+// A name is either a plain name or qualified by package path, such as
+// `github.com/owner/repo/lib.Dbg`.
+// The nil value is a valid Stripper that strips nothing.
+type Stripper struct {
+	calls  map[string]bool
+	consts map[string]bool
+}
+
+// NewStripper returns new Stripper. It returns nil if there is
+// nothing to strip.
+func NewStripper(calls, consts []string) *Stripper {
+	if len(calls) == 0 && len(consts) == 0 {
+		return nil
+	}
+
+	s := &Stripper{
+		calls:  make(map[string]bool),
+		consts: make(map[string]bool),
+	}
+	for _, c := range calls {
+		s.calls[c] = true
+	}
+	for _, c := range consts {
+		s.consts[c] = true
+	}
+	return s
+}
+
+// Package strips the statements of package files.
+// Removed statements are kept in the package. The locals read only
+// from them are assigned to blank identifier, so they are still used.
+func (s *Stripper) Package(pkg *Package) {
+	if s == nil {
+		return
+	}
+
+	info := pkg.Info()
+	match := func(stmt ast.Stmt) bool {
+		switch stmt := stmt.(type) {
+		case *ast.ExprStmt:
+			call, ok := stmt.X.(*ast.CallExpr)
+			return ok && s.matchObject(s.calls, info.Uses[funcIdent(call.Fun)])
+
+		case *ast.IfStmt:
+			id, ok := stmt.Cond.(*ast.Ident)
+			if !ok || stmt.Init != nil {
+				return false
+			}
+			c, ok := info.Uses[id].(*types.Const)
+			if !ok || c.Pkg() == nil || c.Parent() != c.Pkg().Scope() {
+				return false
+			}
+			b, ok := c.Type().Underlying().(*types.Basic)
+			return ok && b.Info()&types.IsBoolean != 0 && s.matchObject(s.consts, c)
+		}
+		return false
+	}
+
+	unused := unusedLocals(pkg.files, info, match)
+	pkg.stripped = stripStmts(pkg.files, match, func(stmt ast.Stmt) (ids []*ast.Ident) {
+		for _, v := range unused[stmt] {
+			id := ast.NewIdent(v.Name())
+			info.Uses[id] = v
+			ids = append(ids, id)
+		}
+		return
+	})
+}
+
+func (s *Stripper) matchObject(names map[string]bool, obj types.Object) bool {
+	switch obj := obj.(type) {
+	case *types.Builtin:
+		return names[obj.Name()]
+	case *types.Func, *types.Const:
+		if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+			// methods and local declarations
+			return false
+		}
+		return names[obj.Name()] || names[obj.Pkg().Path()+"."+obj.Name()]
+	}
+	return false
+}
+
+// String returns the settings. It is a part of cache key.
+func (s *Stripper) String() string {
+	if s == nil {
+		return "Stripper{}"
+	}
+	return fmt.Sprintf("Stripper{calls:%v,consts:%v}", sortedKeys(s.calls), sortedKeys(s.consts))
+}
+
+func sortedKeys(m map[string]bool) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
+
+func funcIdent(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr
+	case *ast.SelectorExpr:
+		return expr.Sel
+	case *ast.ParenExpr:
+		return funcIdent(expr.X)
+	default:
+		return nil
+	}
+}
+
+// strippedStmt is a statement removed by Stripper.
+type strippedStmt struct {
+	pos   token.Pos      // position of the statement
+	blank []string       // locals assigned to blank instead, so they are still used
+	spans []strippedSpan // ranges of source removed from output
+}
+
+// strippedSpan is a range of source removed by Stripper. The lines
+// of the range are removed from output if nothing else is on them.
+// The first line is kept if keepFirst, where the assignment to blank
+// is placed.
+type strippedSpan struct {
+	from, to  token.Pos
+	keepFirst bool
+}
+
+// stripStmts removes statements matched. The statements of else branch
+// of an if statement are spliced into the enclosing block, or the if
+// statement is replaced by the branch if it cannot be.
+// blank returns the locals that are read only from the statement, which
+// are assigned to blank identifier at the place.
+func stripStmts(files []*ast.File, match func(ast.Stmt) bool, blank func(ast.Stmt) []*ast.Ident) (removed []strippedStmt) {
+	for _, file := range files {
+		// removes in post-order, because replaced nodes are not walked.
+		astutil.Apply(file, nil, func(cr *astutil.Cursor) bool {
+			stmt, ok := cr.Node().(ast.Stmt)
+			if !ok || !match(stmt) {
+				return true
+			}
+
+			s := strippedStmt{pos: stmt.Pos()}
+			var stmts []ast.Stmt
+			if ids := blank(stmt); len(ids) != 0 {
+				a := &ast.AssignStmt{TokPos: stmt.Pos(), Tok: token.ASSIGN}
+				for _, id := range ids {
+					id.NamePos = stmt.Pos()
+					a.Lhs = append(a.Lhs, &ast.Ident{NamePos: stmt.Pos(), Name: "_"})
+					a.Rhs = append(a.Rhs, id)
+					s.blank = append(s.blank, id.Name)
+				}
+				stmts = append(stmts, a)
+			}
+			keepFirst := len(stmts) != 0
+
+			switch {
+			case isIfElse(stmt) && cr.Index() >= 0 && splicable(stmt.(*ast.IfStmt).Else):
+				els := stmt.(*ast.IfStmt).Else.(*ast.BlockStmt)
+				stmts = append(stmts, els.List...)
+				s.spans = []strippedSpan{
+					{from: stmt.Pos(), to: els.Lbrace + 1, keepFirst: keepFirst},
+					{from: els.Rbrace, to: els.End()},
+				}
+			case isIfElse(stmt):
+				els := stmt.(*ast.IfStmt).Else
+				stmts = append(stmts, els)
+				s.spans = []strippedSpan{{from: stmt.Pos(), to: els.Pos(), keepFirst: keepFirst}}
+			default:
+				s.spans = []strippedSpan{{from: stmt.Pos(), to: stmt.End(), keepFirst: keepFirst}}
+			}
+			removed = append(removed, s)
+
+			switch {
+			case cr.Index() >= 0:
+				for _, st := range stmts {
+					cr.InsertBefore(st)
+				}
+				cr.Delete()
+			case len(stmts) == 1:
+				cr.Replace(stmts[0])
+			case len(stmts) > 1:
+				cr.Replace(&ast.BlockStmt{Lbrace: stmt.Pos(), List: stmts, Rbrace: stmt.End() - 1})
+			default:
+				cr.Replace(&ast.EmptyStmt{Semicolon: stmt.Pos(), Implicit: true})
+			}
+			return true
+		})
+	}
+	return
+}
+
+func isIfElse(stmt ast.Stmt) bool {
+	s, ok := stmt.(*ast.IfStmt)
+	return ok && s.Else != nil
+}
+
+// splicable reports whether the statements of else branch can be moved
+// into the enclosing block, that is, the branch is a block declaring
+// nothing.
+func splicable(els ast.Stmt) bool {
+	b, ok := els.(*ast.BlockStmt)
+	if !ok {
+		return false
+	}
+	for _, stmt := range b.List {
+		switch stmt := stmt.(type) {
+		case *ast.DeclStmt, *ast.LabeledStmt:
+			return false
+		case *ast.AssignStmt:
+			if stmt.Tok == token.DEFINE {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// unusedLocals returns the local variables that are read only from
+// the statements matched, for each statement. They would be reported
+// as declared and not used after stripping.
+func unusedLocals(files []*ast.File, info *types.Info, match func(ast.Stmt) bool) map[ast.Stmt][]*types.Var {
+	type region struct {
+		stmt     ast.Stmt
+		pos, end token.Pos
+	}
+
+	var regions []region
+	var find func(n ast.Node) bool
+	find = func(n ast.Node) bool {
+		stmt, ok := n.(ast.Stmt)
+		if !ok || !match(stmt) {
+			return true
+		}
+		if s, ok := stmt.(*ast.IfStmt); ok && s.Else != nil {
+			// else branch is left
+			regions = append(regions, region{stmt, s.Pos(), s.Else.Pos()})
+			ast.Inspect(s.Else, find)
+			return false
+		}
+		regions = append(regions, region{stmt, stmt.Pos(), stmt.End()})
+		return false
+	}
+	for _, file := range files {
+		ast.Inspect(file, find)
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+
+	regionOf := func(pos token.Pos) *region {
+		for i := range regions {
+			if regions[i].pos <= pos && pos < regions[i].end {
+				return &regions[i]
+			}
+		}
+		return nil
+	}
+
+	// assigned, not read
+	written := make(map[*ast.Ident]bool)
+	params := make(map[types.Object]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok == token.ASSIGN {
+					for _, e := range n.Lhs {
+						if id, ok := e.(*ast.Ident); ok {
+							written[id] = true
+						}
+					}
+				}
+			case *ast.RangeStmt:
+				if n.Tok == token.ASSIGN {
+					for _, e := range []ast.Expr{n.Key, n.Value} {
+						if id, ok := e.(*ast.Ident); ok {
+							written[id] = true
+						}
+					}
+				}
+			case *ast.FieldList:
+				// parameters, results and receivers need not to be used
+				for _, f := range n.List {
+					for _, id := range f.Names {
+						params[info.Defs[id]] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	type candidate struct {
+		v *types.Var
+		r *region
+	}
+
+	var candidates []candidate
+	used := make(map[*types.Var]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || written[id] {
+				return true
+			}
+			v, ok := info.Uses[id].(*types.Var)
+			if !ok || v.IsField() || params[v] || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() {
+				return true
+			}
+
+			r := regionOf(id.Pos())
+			switch {
+			case r == nil:
+				used[v] = true
+			case v.Pos() < r.pos || r.end <= v.Pos():
+				// declared outside of the statement
+				candidates = append(candidates, candidate{v, r})
+			}
+			return true
+		})
+	}
+
+	m := make(map[ast.Stmt][]*types.Var)
+	for _, c := range candidates {
+		if !used[c.v] && !slices.Contains(m[c.r.stmt], c.v) {
+			m[c.r.stmt] = append(m[c.r.stmt], c.v)
+		}
+	}
+	return m
+}
+
+// dropStrippedComments removes the comments in the source removed by
+// Stripper, including the trailing ones.
+func dropStrippedComments(fset *token.FileSet, pset PackageSet) {
+	for _, pkg := range pset {
+		if len(pkg.stripped) == 0 {
+			continue
+		}
+
+		inside := func(c *ast.Comment) bool {
+			for _, s := range pkg.stripped {
+				for _, sp := range s.spans {
+					if sp.from <= c.Pos() && c.Pos() < sp.to {
+						return true
+					}
+					if c.Pos() >= sp.to && fset.Position(c.Pos()).Line == fset.Position(sp.to).Line {
+						return true
+					}
+				}
+			}
+			return false
+		}
+
+		for _, file := range pkg.files {
+			comments := file.Comments[:0]
+			for _, cg := range file.Comments {
+				list := cg.List[:0]
+				for _, c := range cg.List {
+					if !inside(c) {
+						list = append(list, c)
+					}
+				}
+				if cg.List = list; len(list) != 0 {
+					comments = append(comments, cg)
+				}
+			}
+			file.Comments = comments
+		}
+	}
+}
+
+// strippedFileSet returns a copy of fset to print files, in which the
+// lines removed by Stripper are merged into the previous ones, so that
+// no blank line is left at the places. The positions in fset are left
+// as is for diagnostics. It returns fset if nothing is stripped.
+func strippedFileSet(fset *token.FileSet, pset PackageSet) *token.FileSet {
+	lines := make(map[*token.File]map[int]bool)
+	for _, pkg := range pset {
+		for _, s := range pkg.stripped {
+			for _, sp := range s.spans {
+				tf := fset.File(sp.from)
+				if lines[tf] == nil {
+					lines[tf] = make(map[int]bool)
+				}
+				for _, l := range strippedLines(tf, sp) {
+					lines[tf][l] = true
+				}
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return fset
+	}
+
+	c := token.NewFileSet()
+	fset.Iterate(func(f *token.File) bool {
+		tf := c.AddFile(f.Name(), f.Base(), f.Size())
+		tf.SetLines(f.Lines())
+
+		var ls []int
+		for l := range lines[f] {
+			ls = append(ls, l)
+		}
+		// merges from the bottom, so that the line numbers are not moved
+		sort.Sort(sort.Reverse(sort.IntSlice(ls)))
+		for _, l := range ls {
+			if l > 1 {
+				tf.MergeLine(l - 1)
+			}
+		}
+		return true
+	})
+	return c
+}
+
+// strippedLines returns the lines of the span which nothing but the
+// removed source is on.
+func strippedLines(tf *token.File, sp strippedSpan) (lines []int) {
+	src, err := os.ReadFile(tf.Name())
+	if err != nil || len(src) != tf.Size() {
+		return nil
+	}
+
+	first, last := tf.Line(sp.from), tf.Line(sp.to)
+	for l := first; l <= last; l++ {
+		start, end := tf.Offset(tf.LineStart(l)), len(src)
+		if l < tf.LineCount() {
+			end = tf.Offset(tf.LineStart(l + 1))
+		}
+
+		if l == first {
+			before := src[start:tf.Offset(sp.from)]
+			if sp.keepFirst || len(bytes.TrimSpace(before)) != 0 {
+				continue
+			}
+		}
+		if l == last {
+			after := bytes.TrimSpace(src[tf.Offset(sp.to):end])
+			if len(after) != 0 && !bytes.HasPrefix(after, []byte("//")) {
+				continue
+			}
+		}
+		lines = append(lines, l)
+	}
+	return
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+// The stripped output must compile, so that no local is left unused.
+func TestStripper_typeCheck(t *testing.T) {
+	imp := newImporter()
+	for i, tc := range testdata.Cases {
+		conf := caseConfig(t, tc)
+		if len(conf.StripCalls) == 0 && len(conf.StripConsts) == 0 {
+			continue
+		}
+
+		src, err := os.ReadFile(tc.Expected)
+		if err != nil {
+			t.Fatalf("At: %d, %v", i, err)
+		}
+		for _, err := range typeCheck(src, filepath.Dir(tc.Expected), imp) {
+			t.Errorf("At: %d, %v", i, err)
+		}
+	}
+}
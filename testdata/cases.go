@@ -22,9 +22,10 @@ var (
 	}()
 
 	base     = join(cwd, "testdata", "cases")
-	input    = join("input", "main.go")
+	input    = join("input", "*.go")
 	expected = join("expected", "main.go")
 	actual   = join("actual", "main.go")
+	config   = "config.yml"
 
 	numOfCases = func() int {
 		files, err := os.ReadDir(base)
@@ -38,26 +39,32 @@ var (
 	Cases = initCases(numOfCases)
 )
 
-type testCase struct {
+// Case is a test case of bundling.
+type Case struct {
 	Input,
 	Expected,
 	Actual,
-	ActualDir string
+	ActualDir,
+	Config string // optional. empty if the case has no config file
 }
 
-func newCase(n int) testCase {
+func newCase(n int) Case {
 	s := strconv.Itoa(n)
 	p := func(last string) string { return join(base, s, last) }
-	return testCase{
+	c := Case{
 		Input:     p(input),
 		Expected:  p(expected),
 		Actual:    p(actual),
 		ActualDir: filepath.Dir(p(actual)),
 	}
+	if _, err := os.Stat(p(config)); err == nil {
+		c.Config = p(config)
+	}
+	return c
 }
 
-func initCases(n int) []testCase {
-	v := make([]testCase, n)
+func initCases(n int) []Case {
+	v := make([]Case, n)
 	for i := 0; i < n; i++ {
 		v[i] = newCase(i)
 	}
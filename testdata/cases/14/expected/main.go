@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+func main() {
+	dbg("start")
+	fmt.Println(Name())
+}
+
+func dbg(a ...any) {}
+
+func Name() string { return "submit" }
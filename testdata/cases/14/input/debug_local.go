@@ -0,0 +1,10 @@
+//go:build !gollect
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func dbg(a ...any) { fmt.Fprintln(os.Stderr, a...) }
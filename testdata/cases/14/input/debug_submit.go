@@ -0,0 +1,5 @@
+//go:build gollect
+
+package main
+
+func dbg(a ...any) {}
@@ -0,0 +1,5 @@
+//go:build gollect
+
+package lib
+
+func Name() string { return "submit" }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/14/input/lib"
+)
+
+func main() {
+	dbg("start")
+	fmt.Println(lib.Name())
+}
@@ -0,0 +1,14 @@
+package main
+
+import "fmt"
+
+func main() {
+	n := Sum(1, 2)
+
+	fmt.Println(n)
+	println("builtin is not stripped unless listed")
+}
+
+func Sum(a, b int) int {
+	return a + b
+}
@@ -0,0 +1,18 @@
+package lib
+
+import "fmt"
+
+func Sum(a, b int) int {
+	Assert(a >= 0 && b >= 0)
+	return a + b
+}
+
+func Assert(ok bool) {
+	if !ok {
+		panic(msg)
+	}
+}
+
+func Dump(n int) string { return fmt.Sprint(n) }
+
+const msg = "assertion failed"
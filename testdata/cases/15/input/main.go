@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/15/input/lib"
+)
+
+const debug = false
+
+func dbg(a ...any) { fmt.Println(a...) }
+
+func main() {
+	n := lib.Sum(1, 2)
+	dbg("n =", n)
+
+	if debug {
+		fmt.Println(lib.Dump(n))
+	}
+
+	if debug {
+		dbg("debug")
+	} else {
+		fmt.Println(n)
+		println("builtin is not stripped unless listed")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// S is sortable.
+type S []int
+
+func (s S) Len() int           { return len(s) }
+func (s S) Less(i, j int) bool { return s[i] < s[j] }
+func (s S) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func main() {
+	s := S{3, 1, 2}
+	sort.Sort(s)
+	fmt.Println(s, T{}.Get())
+}
+
+func solve() { fmt.Println(helper()) }
+
+func helper() int { return 1 }
+
+var table = []int{1, 2}
+
+type T struct{}
+
+func (T) Get() int { return 1 }
+
+// String is left because T is used.
+func (T) String() string { return "T" }
+
+type U struct{}
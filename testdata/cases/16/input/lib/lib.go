@@ -0,0 +1,21 @@
+package lib
+
+type T struct{}
+
+func (T) Get() int { return 1 }
+
+// String is left because T is used.
+// gollect: keep
+func (T) String() string { return "T" }
+
+func (T) Unused() {}
+
+type (
+	// gollect: keep
+	U struct{}
+
+	V struct{}
+)
+
+// gollect: exclude
+func Heavy() {}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/murosan/gollect/testdata/cases/16/input/lib"
+)
+
+// S is sortable.
+// gollect: keep methods Len Less Swap
+type S []int
+
+func (s S) Len() int           { return len(s) }
+func (s S) Less(i, j int) bool { return s[i] < s[j] }
+func (s S) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s S) Unused()            {}
+
+func main() {
+	s := S{3, 1, 2}
+	sort.Sort(s)
+	fmt.Println(s, lib.T{}.Get())
+}
+
+// gollect: root
+func solve() { fmt.Println(helper()) }
+
+func helper() int { return 1 }
+
+// gollect: keep
+var table = []int{1, 2}
+
+func unused() {}
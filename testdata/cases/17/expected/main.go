@@ -0,0 +1,25 @@
+func twoSum(nums []int, target int) []int {
+	idx := Index(nums)
+	for i, n := range nums {
+		if j, ok := idx[target-n]; ok && i != j {
+			return []int{i, j}
+		}
+	}
+	return nil
+}
+
+type MinStack struct{ s []int }
+
+func Constructor() MinStack { return MinStack{} }
+
+func (m *MinStack) Push(x int) { m.s = append(m.s, x) }
+func (m *MinStack) Pop()       { m.s = m.s[:len(m.s)-1] }
+func (m *MinStack) Top() int   { return m.s[len(m.s)-1] }
+
+func Index(a []int) map[int]int {
+	m := make(map[int]int, len(a))
+	for i, v := range a {
+		m[v] = i
+	}
+	return m
+}
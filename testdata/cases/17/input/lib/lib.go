@@ -0,0 +1,11 @@
+package lib
+
+func Index(a []int) map[int]int {
+	m := make(map[int]int, len(a))
+	for i, v := range a {
+		m[v] = i
+	}
+	return m
+}
+
+func Unused() {}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/17/input/lib"
+)
+
+// main is used only for local testing.
+func main() {
+	fmt.Println(twoSum([]int{2, 7, 11, 15}, 9))
+
+	s := Constructor()
+	s.Push(1)
+	fmt.Println(s.Top())
+}
+
+func twoSum(nums []int, target int) []int {
+	idx := lib.Index(nums)
+	for i, n := range nums {
+		if j, ok := idx[target-n]; ok && i != j {
+			return []int{i, j}
+		}
+	}
+	return nil
+}
+
+type MinStack struct{ s []int }
+
+func Constructor() MinStack { return MinStack{} }
+
+func (m *MinStack) Push(x int) { m.s = append(m.s, x) }
+func (m *MinStack) Pop()       { m.s = m.s[:len(m.s)-1] }
+func (m *MinStack) Top() int   { return m.s[len(m.s)-1] }
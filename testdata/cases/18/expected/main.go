@@ -0,0 +1,38 @@
+package snapshot
+
+// Inf is a large value.
+const Inf = 1 << 60
+
+// Dx and Dy are the moves to four neighbors.
+var Dx, Dy = []int{0, 1, 0, -1}, []int{1, 0, -1, 0}
+
+// Max returns the larger one.
+func Max(a, b int) int {
+	if Less(a, b) {
+		return b
+	}
+	return a
+}
+
+// Stack is a LIFO stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// NewStack returns an empty stack.
+func NewStack[T any]() *Stack[T] { return &Stack[T]{} }
+
+// Push pushes v.
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+// Pop pops the last value.
+func (s *Stack[T]) Pop() T {
+	v := s.top()
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+func (s *Stack[T]) top() T { return s.items[len(s.items)-1] }
+
+// Less reports whether a is less than b.
+func Less(a, b int) bool { return a < b }
@@ -0,0 +1,27 @@
+// Package lib is a library for snapshot.
+package lib
+
+import "github.com/murosan/gollect/testdata/cases/18/input/util"
+
+// Inf is a large value.
+const Inf = 1 << 60
+
+const unused = 1
+
+// Dx and Dy are the moves to four neighbors.
+var Dx, Dy = []int{0, 1, 0, -1}, []int{1, 0, -1, 0}
+
+// Max returns the larger one.
+func Max(a, b int) int {
+	if util.Less(a, b) {
+		return b
+	}
+	return a
+}
+
+func min(a, b int) int {
+	if util.Less(a, b) {
+		return a
+	}
+	return b
+}
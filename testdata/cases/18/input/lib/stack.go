@@ -0,0 +1,27 @@
+package lib
+
+// Stack is a LIFO stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// NewStack returns an empty stack.
+func NewStack[T any]() *Stack[T] { return &Stack[T]{} }
+
+// Push pushes v.
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+// Pop pops the last value.
+func (s *Stack[T]) Pop() T {
+	v := s.top()
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+func (s *Stack[T]) top() T { return s.items[len(s.items)-1] }
+
+func (s *Stack[T]) unused() {}
+
+type node struct{}
+
+func (node) Exported() {}
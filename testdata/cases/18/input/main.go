@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/18/input/lib"
+)
+
+func main() {
+	s := lib.NewStack[int]()
+	s.Push(1)
+	fmt.Println(s.Pop(), lib.Max(1, 2), lib.Dx[0]+lib.Dy[0])
+}
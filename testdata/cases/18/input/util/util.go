@@ -0,0 +1,7 @@
+package util
+
+// Less reports whether a is less than b.
+func Less(a, b int) bool { return a < b }
+
+// Greater reports whether a is greater than b.
+func Greater(a, b int) bool { return a > b }
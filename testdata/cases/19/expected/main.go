@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Answer struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+}
+
+func main() {
+	var n int
+	fmt.Scan(&n)
+	q := NewQueue()
+	for i := 0; i < n; i++ {
+		q.Push(i)
+	}
+	b, _ := json.Marshal(Answer{Value: Sum(q), Label: Describe(n)})
+	fmt.Println(string(b), Usage)
+}
+
+const Usage = `usage:
+
+  main < input`
+const a = 1 << 10
+
+type base struct{ size int }
+type Queue struct {
+	base
+	items []int
+}
+
+func NewQueue() *Queue { return &Queue{items: make([]int, 0, a)} }
+func (d *Queue) Push(e int) {
+	d.items = append(d.items, e)
+	d.size++
+}
+func Sum(d *Queue) int {
+	e := 0
+	for _, f := range d.items {
+		e = c(e, f)
+	}
+	return e
+}
+
+//go:noinline
+func c(d, e int) int { return d + e }
+func Describe(d interface{}) string {
+	switch e := d.(type) {
+	case int:
+		return fmt.Sprint("int:", e)
+	case string:
+		return "string:" + e
+	default:
+		return fmt.Sprint(e)
+	}
+}
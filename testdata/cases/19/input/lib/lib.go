@@ -0,0 +1,53 @@
+// Package lib is a library.
+package lib
+
+import "fmt"
+
+// Usage is printed with the answer.
+const Usage = `usage:
+
+  main < input`
+
+// maxSize is the capacity of queue.
+const maxSize = 1 << 10
+
+type base struct{ size int }
+
+// Queue is a FIFO queue.
+type Queue struct {
+	base
+	items []int // values
+}
+
+// NewQueue returns an empty queue.
+func NewQueue() *Queue { return &Queue{items: make([]int, 0, maxSize)} }
+
+// Push pushes a value.
+func (q *Queue) Push(value int) {
+	q.items = append(q.items, value)
+	q.size++
+}
+
+// Sum returns the sum of values.
+func Sum(queue *Queue) int {
+	total := 0
+	for _, value := range queue.items {
+		total = add(total, value)
+	}
+	return total
+}
+
+//go:noinline
+func add(left, right int) int { return left + right }
+
+// Describe describes the value.
+func Describe(value interface{}) string {
+	switch typed := value.(type) {
+	case int:
+		return fmt.Sprint("int:", typed)
+	case string:
+		return "string:" + typed
+	default:
+		return fmt.Sprint(typed)
+	}
+}
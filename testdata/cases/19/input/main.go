@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/19/input/lib"
+)
+
+// Answer is printed as json.
+type Answer struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+}
+
+func main() {
+	// read input
+	var n int
+	fmt.Scan(&n)
+
+	q := lib.NewQueue()
+	for i := 0; i < n; i++ {
+		q.Push(i)
+	}
+
+	b, _ := json.Marshal(Answer{Value: lib.Sum(q), Label: lib.Describe(n)})
+	fmt.Println(string(b), lib.Usage)
+}
@@ -0,0 +1,30 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Gcd(12, 18), Lcm(4, 6), Abs(-1))
+}
+
+// --- from github.com/murosan/gollect/testdata/cases/20/input/lib (abs.go) ---
+
+// Abs returns the absolute value.
+func Abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// --- from github.com/murosan/gollect/testdata/cases/20/input/lib (gcd.go) ---
+
+// Gcd returns the greatest common divisor.
+func Gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Lcm returns the least common multiple.
+func Lcm(a, b int) int { return a / Gcd(a, b) * b }
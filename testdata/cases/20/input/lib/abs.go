@@ -0,0 +1,11 @@
+package lib
+
+// Abs returns the absolute value.
+func Abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func unused() {}
@@ -0,0 +1,12 @@
+package lib
+
+// Gcd returns the greatest common divisor.
+func Gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Lcm returns the least common multiple.
+func Lcm(a, b int) int { return a / Gcd(a, b) * b }
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/20/input/lib"
+)
+
+func main() {
+	fmt.Println(lib.Gcd(12, 18), lib.Lcm(4, 6), lib.Abs(-1))
+}
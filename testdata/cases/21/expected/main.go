@@ -0,0 +1,68 @@
+package main
+
+import "fmt"
+
+type Item struct{ v int }
+
+func (a Item) Less(b Item) bool { return a.v < b.v }
+func (a Item) Key() int         { return a.v }
+
+type Rev int
+
+func (a *Rev) Less(b *Rev) bool { return *a > *b }
+
+// Key is promoted from Item.
+type Boxed struct{ Item }
+
+type Name string
+
+func (a Name) Less(b Name) bool { return a < b }
+
+func main() {
+	fmt.Println(Min(Item{1}, Item{2}))
+
+	a, b := Rev(1), Rev(2)
+	fmt.Println(*MinOf(&a, &b))
+
+	fmt.Println(KeyOf(Boxed{Item{3}}))
+
+	var h Heap[Name]
+	h.Push("b")
+	h.Push("a")
+}
+
+type Lesser[T any] interface {
+	Less(T) bool
+}
+
+func Min[T Lesser[T]](a, b T) T {
+	if b.Less(a) {
+		return b
+	}
+	return a
+}
+
+// MinOf passes its type parameter to Min.
+func MinOf[T Lesser[T]](a ...T) T {
+	m := a[0]
+	for _, v := range a {
+		m = Min(m, v)
+	}
+	return m
+}
+
+func KeyOf[T interface{ Key() int }](v T) int { return v.Key() }
+
+type Heap[T interface{ Less(T) bool }] struct {
+	data []T
+}
+
+func (h *Heap[T]) Push(v T) {
+	h.data = append(h.data, v)
+	for i := len(h.data) - 1; i > 0; i-- {
+		if !h.data[i].Less(h.data[i-1]) {
+			break
+		}
+		h.data[i], h.data[i-1] = h.data[i-1], h.data[i]
+	}
+}
@@ -0,0 +1,39 @@
+package lib
+
+type Lesser[T any] interface {
+	Less(T) bool
+}
+
+func Min[T Lesser[T]](a, b T) T {
+	if b.Less(a) {
+		return b
+	}
+	return a
+}
+
+// MinOf passes its type parameter to Min.
+func MinOf[T Lesser[T]](a ...T) T {
+	m := a[0]
+	for _, v := range a {
+		m = Min(m, v)
+	}
+	return m
+}
+
+func KeyOf[T interface{ Key() int }](v T) int { return v.Key() }
+
+type Heap[T interface{ Less(T) bool }] struct {
+	data []T
+}
+
+func (h *Heap[T]) Push(v T) {
+	h.data = append(h.data, v)
+	for i := len(h.data) - 1; i > 0; i-- {
+		if !h.data[i].Less(h.data[i-1]) {
+			break
+		}
+		h.data[i], h.data[i-1] = h.data[i-1], h.data[i]
+	}
+}
+
+func (h *Heap[T]) Len() int { return len(h.data) }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/21/input/lib"
+)
+
+type Item struct{ v int }
+
+func (a Item) Less(b Item) bool { return a.v < b.v }
+func (a Item) Key() int         { return a.v }
+func (a Item) Unused()          {}
+
+type Rev int
+
+func (a *Rev) Less(b *Rev) bool { return *a > *b }
+
+// Key is promoted from Item.
+type Boxed struct{ Item }
+
+type Name string
+
+func (a Name) Less(b Name) bool { return a < b }
+
+func main() {
+	fmt.Println(lib.Min(Item{1}, Item{2}))
+
+	a, b := Rev(1), Rev(2)
+	fmt.Println(*lib.MinOf(&a, &b))
+
+	fmt.Println(lib.KeyOf(Boxed{Item{3}}))
+
+	var h lib.Heap[Name]
+	h.Push("b")
+	h.Push("a")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type inner struct {
+	sort.Interface
+}
+
+// S embeds a pointer of the struct embedding sort.Interface.
+type S struct {
+	*inner
+	data []int
+}
+
+func (s *S) Len() int           { return len(s.data) }
+func (s *S) Less(i, j int) bool { return s.data[i] < s.data[j] }
+func (s *S) Swap(i, j int)      { s.data[i], s.data[j] = s.data[j], s.data[i] }
+
+func main() {
+	s := &S{inner: &inner{}, data: []int{3, 1, 2}}
+	sort.Sort(s)
+	fmt.Println(s.data)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+type Comparator[T, U any] interface {
+	Compare(a T, b U) int
+}
+
+// S embeds the interface instantiated with multiple type arguments.
+type S struct {
+	Comparator[int, string]
+}
+
+func (S) Compare(a int, b string) int { return a - len(b) }
+func (S) Unused()                     {}
+
+func compare(c Comparator[int, string]) int { return c.Compare(3, "ab") }
+
+func main() {
+	fmt.Println(compare(S{}))
+}
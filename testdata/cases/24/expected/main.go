@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Sorter = sort.Interface
+
+type Comparator[T any] interface {
+	Compare(a, b T) int
+}
+
+type IntComparator = Comparator[int]
+
+// S embeds the aliases of interfaces.
+type S struct {
+	Sorter
+	IntComparator
+	data []int
+}
+
+func (s *S) Len() int           { return len(s.data) }
+func (s *S) Less(i, j int) bool { return s.Compare(s.data[i], s.data[j]) < 0 }
+func (s *S) Swap(i, j int)      { s.data[i], s.data[j] = s.data[j], s.data[i] }
+func (s *S) Compare(a, b int) int {
+	return a - b
+}
+
+func main() {
+	s := &S{data: []int{3, 1, 2}}
+	sort.Sort(s)
+	fmt.Println(s.data)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Stack interface {
+	sort.Interface
+	Push(x int)
+}
+
+// S embeds the interface embedding sort.Interface.
+type S struct {
+	Stack
+	data []int
+}
+
+func (s *S) Len() int           { return len(s.data) }
+func (s *S) Less(i, j int) bool { return s.data[i] < s.data[j] }
+func (s *S) Swap(i, j int)      { s.data[i], s.data[j] = s.data[j], s.data[i] }
+func (s *S) Push(x int)         { s.data = append(s.data, x) }
+
+func push(s Stack, a ...int) {
+	for _, x := range a {
+		s.Push(x)
+	}
+}
+
+func main() {
+	s := &S{}
+	push(s, 3, 1, 2)
+	sort.Sort(s)
+	fmt.Println(s.data)
+}
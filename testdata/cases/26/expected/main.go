@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+type IntStack = Stack[int]
+
+// Len is promoted through the alias chain.
+type W struct{ *G }
+
+func main() {
+	var g *Graph = New(3)
+	g.AddEdge(0, 1)
+
+	var s Stack[string]
+	s.Push("a")
+
+	var t IntStack
+	t.Push(1)
+	fmt.Println(s.Pop(), t.Pop())
+
+	set := Set[int]{1: {}}
+	fmt.Println(len(set))
+
+	w := W{g}
+	fmt.Println(w.Len())
+}
+
+type Stack[T any] struct {
+	data []T
+}
+
+func (s *Stack[T]) Push(v T) { s.data = append(s.data, v) }
+func (s *Stack[T]) Pop() T {
+	v := s.data[len(s.data)-1]
+	s.data = s.data[:len(s.data)-1]
+	return v
+}
+
+type Set[T comparable] = map[T]struct{}
+
+type Graph struct {
+	adj [][]int
+}
+
+func New(n int) *Graph { return &Graph{adj: make([][]int, n)} }
+
+func (g *Graph) AddEdge(u, v int) { g.adj[u] = append(g.adj[u], v) }
+func (g *Graph) Len() int         { return len(g.adj) }
+
+// G is an alias in the middle of the chain.
+type G = Graph
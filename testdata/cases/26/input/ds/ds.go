@@ -0,0 +1,15 @@
+package ds
+
+type Stack[T any] struct {
+	data []T
+}
+
+func (s *Stack[T]) Push(v T) { s.data = append(s.data, v) }
+func (s *Stack[T]) Pop() T {
+	v := s.data[len(s.data)-1]
+	s.data = s.data[:len(s.data)-1]
+	return v
+}
+func (s *Stack[T]) Len() int { return len(s.data) }
+
+type Set[T comparable] = map[T]struct{}
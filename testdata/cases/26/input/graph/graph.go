@@ -0,0 +1,11 @@
+package graph
+
+type Graph struct {
+	adj [][]int
+}
+
+func New(n int) *Graph { return &Graph{adj: make([][]int, n)} }
+
+func (g *Graph) AddEdge(u, v int) { g.adj[u] = append(g.adj[u], v) }
+func (g *Graph) Len() int         { return len(g.adj) }
+func (g *Graph) Unused()          {}
@@ -0,0 +1,6 @@
+package graph2
+
+import "github.com/murosan/gollect/testdata/cases/26/input/graph"
+
+// G is an alias in the middle of the chain.
+type G = graph.Graph
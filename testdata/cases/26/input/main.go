@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/26/input/ds"
+	"github.com/murosan/gollect/testdata/cases/26/input/graph"
+	"github.com/murosan/gollect/testdata/cases/26/input/graph2"
+)
+
+// aliases to the types of the same names are removed
+type (
+	Graph             = graph.Graph
+	G                 = graph2.G
+	Stack[T any]      = ds.Stack[T]
+	Set[T comparable] = ds.Set[T]
+)
+
+type IntStack = ds.Stack[int]
+
+// Len is promoted through the alias chain.
+type W struct{ *G }
+
+func main() {
+	var g *Graph = graph.New(3)
+	g.AddEdge(0, 1)
+
+	var s Stack[string]
+	s.Push("a")
+
+	var t IntStack
+	t.Push(1)
+	fmt.Println(s.Pop(), t.Pop())
+
+	set := Set[int]{1: {}}
+	fmt.Println(len(set))
+
+	w := W{g}
+	fmt.Println(w.Len())
+}
@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Print(Greeting)
+	fmt.Print(Quote())
+}
+
+// Greeting is embedded as string.
+var Greeting = `hello, world
+	with a tab
+`
+
+var quote = []byte("say \"`hi`\"\n")
+
+// Quote returns the embedded quote.
+func Quote() string { return string(quote) }
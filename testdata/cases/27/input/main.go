@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/27/input/text"
+)
+
+func main() {
+	fmt.Print(text.Greeting)
+	fmt.Print(text.Quote())
+}
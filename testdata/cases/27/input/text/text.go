@@ -0,0 +1,19 @@
+package text
+
+import _ "embed"
+
+// Greeting is embedded as string.
+//
+//go:embed greeting.txt
+var Greeting string
+
+//go:embed data/quote.txt
+var quote []byte
+
+var (
+	//go:embed data/quote.txt
+	unused string
+)
+
+// Quote returns the embedded quote.
+func Quote() string { return string(quote) }
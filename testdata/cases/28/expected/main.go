@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+var half = float32(0.5)
+
+func main() {
+	fmt.Println(Primes, Fact, Square("c"), Grid(), half)
+}
+
+// Primes are the prime numbers less than 50.
+var Primes = []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47}
+
+var Fact = [5]int64{1, 1, 2, 6, 24}
+
+var (
+	squares = map[string][]int{"a": {0, 0}, "b": {1, 1}, "c": {2, 4}, "d": {3, 9}}
+
+	grid = [][]float64(nil)
+)
+
+// Square returns the square of i.
+func Square(s string) int { return squares[s][1] }
+
+// Grid returns nothing.
+func Grid() [][]float64 { return grid }
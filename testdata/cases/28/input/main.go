@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/28/input/table"
+)
+
+// gollect: precompute
+var half = ratio(1, 2)
+
+func ratio(a, b float32) float32 { return a / b }
+
+func main() {
+	fmt.Println(table.Primes, table.Fact, table.Square("c"), table.Grid(), half)
+}
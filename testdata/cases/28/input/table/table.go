@@ -0,0 +1,58 @@
+package table
+
+// Primes are the prime numbers less than 50.
+//
+// gollect: precompute
+var Primes = sieve(50)
+
+// gollect: precompute
+var Fact = factorials(10)
+
+var (
+	// gollect: precompute
+	squares = squareMap(4)
+
+	// gollect: precompute
+	grid = [][]float64(nil)
+)
+
+// gollect: precompute
+var unused = sieve(1000)
+
+func sieve(n int) []int {
+	composite := make([]bool, n)
+	var primes []int
+	for i := 2; i < n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j < n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+func factorials(n int) [5]int64 {
+	var a [5]int64
+	a[0] = 1
+	for i := 1; i < len(a); i++ {
+		a[i] = a[i-1] * int64(i)
+	}
+	return a
+}
+
+func squareMap(n int) map[string][]int {
+	m := make(map[string][]int)
+	for i := 0; i < n; i++ {
+		m[string(rune('a'+i))] = []int{i, i * i}
+	}
+	return m
+}
+
+// Square returns the square of i.
+func Square(s string) int { return squares[s][1] }
+
+// Grid returns nothing.
+func Grid() [][]float64 { return grid }
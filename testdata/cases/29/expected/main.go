@@ -0,0 +1,20 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(Add(1, 2), Mul(3, 4))
+}
+
+// Add adds the values.
+//
+//go:noinline
+func Add(a, b int) int { return a + b }
+
+//go:nosplit
+func mul(a, b int) int { return a * b }
+
+// Mul multiplies the values.
+//
+//go:norace
+func Mul(a, b int) int { return mul(a, b) }
@@ -0,0 +1,21 @@
+// Copyright 2020 someone.
+
+//go:build go1.21
+// +build go1.21
+
+//go:generate echo generated
+
+// Package fast has functions with compiler directives.
+package fast
+
+// Add adds the values.
+//
+//go:noinline
+func Add(a, b int) int { return a + b }
+
+//go:nosplit
+func mul(a, b int) int { return a * b }
+
+// Mul multiplies the values.
+//go:norace
+func Mul(a, b int) int { return mul(a, b) }
@@ -0,0 +1,15 @@
+//go:build go1.21
+
+//go:debug panicnil=1
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/29/input/fast"
+)
+
+func main() {
+	fmt.Println(fast.Add(1, 2), fast.Mul(3, 4))
+}
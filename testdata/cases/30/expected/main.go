@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"github.com/murosan/gollect/testdata/cases/30/input/lib"
+)
+
+func main() {
+	fmt.Println(lib.Double(Max(1, 2)))
+}
+
+// Max returns the larger value.
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,4 @@
+package lib
+
+// Double returns a doubled value.
+func Double(a int) int { return a * 2 }
@@ -0,0 +1,14 @@
+//gollect:allow github.com/murosan/gollect/testdata/cases/30/input/lib
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/30/input/lib"
+	"github.com/murosan/gollect/testdata/cases/30/input/util"
+)
+
+func main() {
+	fmt.Println(lib.Double(util.Max(1, 2)))
+}
@@ -0,0 +1,10 @@
+package util
+
+// Max returns the larger value.
+//gollect:allow github.com/murosan/gollect/testdata/cases/30/input/other
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
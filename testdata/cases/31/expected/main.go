@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func main() {
+	n := 3
+	sq := n * n
+	_ = sq
+
+	name := Name(n)
+	_ = name
+
+	var cnt int
+	for i := 0; i < n; i++ {
+		cnt = i
+	}
+	_ = cnt
+
+	// only the release branch is left
+	fmt.Println(Cube(n))
+}
+
+func Name(n int) string { return "n" + strconv.Itoa(n) }
+
+func Cube(n int) int {
+	sq := n * n
+	_ = sq
+	return n * n * n
+}
@@ -0,0 +1,17 @@
+package lib
+
+import "strconv"
+
+func Name(n int) string { return "n" + strconv.Itoa(n) }
+
+func Cube(n int) int {
+	sq := n * n
+	Assert(sq >= 0)
+	return n * n * n
+}
+
+func Assert(ok bool) {
+	if !ok {
+		panic("assertion failed")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/31/input/lib"
+)
+
+const debug = false
+
+func dbg(a ...any) { fmt.Println(a...) }
+
+func main() {
+	n := 3
+	sq := n * n
+	dbg("sq", sq) // only sq is left used
+
+	name := lib.Name(n)
+	if debug {
+		fmt.Println(name)
+	}
+
+	var cnt int
+	for i := 0; i < n; i++ {
+		cnt = i
+	}
+	dbg(cnt)
+
+	if debug {
+		dbg("debug")
+	} else {
+		// only the release branch is left
+		dbg("release")
+		fmt.Println(lib.Cube(n))
+	}
+}
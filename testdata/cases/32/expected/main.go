@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+func main() {
+	// Inner is referenced before Outer
+	in := Inner{}
+	_ = in
+
+	w := Wrapper{Base: &Base{}}
+	w.Hello()
+
+	var o Outer
+	o.Inc()
+	fmt.Println(o.Value())
+}
+
+type Base struct{ name string }
+
+func (b *Base) Hello() { fmt.Println("hello", b.name) }
+
+// Wrapper embeds a pointer.
+type Wrapper struct{ *Base }
+
+type Inner struct{ n int }
+
+func (i Inner) Value() int { return i.n }
+
+func (i *Inner) Inc() { i.n++ }
+
+// Outer embeds a value.
+type Outer struct {
+	Inner
+	label string
+}
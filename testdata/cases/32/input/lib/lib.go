@@ -0,0 +1,24 @@
+package lib
+
+import "fmt"
+
+type Base struct{ name string }
+
+func (b *Base) Hello() { fmt.Println("hello", b.name) }
+
+func (b *Base) unused() {}
+
+// Wrapper embeds a pointer.
+type Wrapper struct{ *Base }
+
+type Inner struct{ n int }
+
+func (i Inner) Value() int { return i.n }
+
+func (i *Inner) Inc() { i.n++ }
+
+// Outer embeds a value.
+type Outer struct {
+	Inner
+	label string
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/32/input/lib"
+)
+
+func main() {
+	// Inner is referenced before Outer
+	in := lib.Inner{}
+	_ = in
+
+	w := lib.Wrapper{Base: &lib.Base{}}
+	w.Hello()
+
+	var o lib.Outer
+	o.Inc()
+	fmt.Println(o.Value())
+}
@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+func main() {
+	// embedded types are referenced before the types embedding them
+	n, c := Name{}, &Counter{}
+
+	var s fmt.Stringer = Tagged{Name: n}
+	var l interface{ Len() int } = Counted{Counter: c}
+	fmt.Println(s, l.Len())
+}
+
+type Name struct{ s string }
+
+func (n Name) String() string { return n.s }
+
+type Counter struct{ n int }
+
+func (c *Counter) Len() int { return c.n }
+
+// Tagged embeds a value.
+type Tagged struct {
+	Name
+	tag int
+}
+
+// Counted embeds a pointer.
+type Counted struct{ *Counter }
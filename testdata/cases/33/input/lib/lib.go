@@ -0,0 +1,20 @@
+package lib
+
+type Name struct{ s string }
+
+func (n Name) String() string { return n.s }
+
+type Counter struct{ n int }
+
+func (c *Counter) Len() int { return c.n }
+
+// Tagged embeds a value.
+// gollect: keep methods
+type Tagged struct {
+	Name
+	tag int
+}
+
+// Counted embeds a pointer.
+// gollect: keep methods Len
+type Counted struct{ *Counter }
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/cases/33/input/lib"
+)
+
+func main() {
+	// embedded types are referenced before the types embedding them
+	n, c := lib.Name{}, &lib.Counter{}
+
+	var s fmt.Stringer = lib.Tagged{Name: n}
+	var l interface{ Len() int } = lib.Counted{Counter: c}
+	fmt.Println(s, l.Len())
+}
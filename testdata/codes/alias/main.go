@@ -0,0 +1,11 @@
+package main
+
+import "github.com/murosan/gollect/testdata/cases/26/input/ds"
+
+// becomes `type Stack = Stack[int]` after bundling
+type Stack = ds.Stack[int]
+
+func main() {
+	var s Stack
+	s.Push(1)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/check/lib/a"
+	"github.com/murosan/gollect/testdata/codes/check/lib/b"
+)
+
+func main() {
+	fmt.Println(a.Max(1, 2), b.Max(3, 4, 5))
+}
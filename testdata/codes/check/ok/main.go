@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/check/lib/a"
+)
+
+func main() {
+	var x, y int
+	fmt.Scan(&x, &y)
+	fmt.Println(a.Max(x, y))
+}
@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+func main() {
+	var x, y int
+	fmt.Scan(&x, &y)
+	fmt.Println(Max(x, y))
+}
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,10 @@
+// Package main is a solution.
+package main
+
+import "github.com/murosan/gollect/testdata/codes/comments/pkg"
+
+// main is the entry.
+func main() {
+	// calls pkg
+	pkg.Run() // run
+}
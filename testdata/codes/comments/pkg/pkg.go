@@ -0,0 +1,29 @@
+// Copyright 2020 someone. All rights reserved.
+// license header.
+
+// Package pkg is a library.
+package pkg
+
+// floating comment.
+
+// Run runs.
+//
+//go:noinline
+func Run() {
+	// interior comment
+	x := value // line comment
+	println(x)
+}
+
+/* unused */
+func unused() {}
+
+const (
+	// value doc
+	value = 1 // value comment
+
+	// other doc
+	other = 2
+)
+
+// trailing comment.
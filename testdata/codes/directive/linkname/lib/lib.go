@@ -0,0 +1,10 @@
+package lib
+
+import _ "unsafe"
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func Now() int64 { return nanotime() }
+
+func Zero() int64 { return 0 }
@@ -0,0 +1,9 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/directive/linkname/lib"
+)
+
+func main() { fmt.Println(lib.Now()) }
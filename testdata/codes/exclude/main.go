@@ -0,0 +1,5 @@
+package main
+
+import "github.com/murosan/gollect/testdata/codes/exclude/pkg"
+
+func main() { pkg.Light() }
@@ -0,0 +1,6 @@
+package pkg
+
+func Light() { heavy() }
+
+// gollect: exclude
+func heavy() {}
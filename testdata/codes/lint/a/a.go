@@ -0,0 +1,26 @@
+package a
+
+import (
+	"embed"
+	_ "image/png"
+	. "strings"
+	"unsafe"
+)
+
+//go:embed a.txt
+var text string
+
+//go:embed a.txt
+var assets embed.FS
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func Upper(s string) string { return ToUpper(s) + string(unsafe.Slice(&text, 0)) }
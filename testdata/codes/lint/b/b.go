@@ -0,0 +1,15 @@
+package b
+
+/*
+int add(int a, int b) { return a + b; }
+*/
+import "C"
+
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func sum(a []int) int
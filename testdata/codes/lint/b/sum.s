@@ -0,0 +1 @@
+// assembly
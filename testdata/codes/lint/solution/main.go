@@ -0,0 +1,7 @@
+package main
+
+import . "fmt"
+
+func Max() {}
+
+func main() { Println() }
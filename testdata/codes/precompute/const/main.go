@@ -0,0 +1,8 @@
+package main
+
+import "fmt"
+
+// gollect: precompute
+const n = 10
+
+func main() { fmt.Println(n) }
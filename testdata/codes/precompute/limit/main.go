@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// gollect: precompute
+var a = seq(100)
+
+func seq(n int) []int {
+	a := make([]int, n)
+	for i := range a {
+		a[i] = i
+	}
+	return a
+}
+
+func main() { fmt.Println(a) }
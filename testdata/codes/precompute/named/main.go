@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+type Table []int
+
+// gollect: precompute
+var table = build()
+
+func build() Table { return Table{1, 2} }
+
+func main() { fmt.Println(table) }
@@ -0,0 +1,10 @@
+package main
+
+import "fmt"
+
+// gollect: precompute
+var p = newInt()
+
+func newInt() *int { return new(int) }
+
+func main() { fmt.Println(*p) }
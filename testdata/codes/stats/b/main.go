@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/stats/lib/ds"
+	"github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+)
+
+func main() {
+	var s ds.Stack
+	s.Push(mathx.Max(3, 4))
+	fmt.Println(s)
+}
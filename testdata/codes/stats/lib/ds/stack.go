@@ -0,0 +1,19 @@
+package ds
+
+import "github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+
+type Stack struct {
+	a   []int
+	max int
+}
+
+func (s *Stack) Push(v int) {
+	s.a = append(s.a, v)
+	s.max = mathx.Max(s.max, v)
+}
+
+func (s *Stack) Pop() int {
+	v := s.a[len(s.a)-1]
+	s.a = s.a[:len(s.a)-1]
+	return v
+}
@@ -0,0 +1,15 @@
+package graph
+
+import "github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+
+// Graph is imported by no solution.
+type Graph [][]int
+
+func (g Graph) AddEdge(u, v int) { g[u] = append(g[u], v) }
+
+func MaxDegree(g Graph) (d int) {
+	for _, e := range g {
+		d = mathx.Max(d, len(e))
+	}
+	return
+}
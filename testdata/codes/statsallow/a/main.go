@@ -0,0 +1,15 @@
+//gollect:allow github.com/murosan/gollect/testdata/codes/stats/lib/ds
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/stats/lib/ds"
+)
+
+func main() {
+	var s ds.Stack
+	s.Push(1)
+	fmt.Println(s)
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/stats/lib/ds"
+)
+
+func main() {
+	var s ds.Stack
+	s.Push(2)
+	fmt.Println(s)
+}
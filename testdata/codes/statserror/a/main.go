@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/murosan/gollect/testdata/codes/stats/lib/mathx"
+)
+
+func main() {
+	fmt.Println(mathx.Max(1, 2))
+}
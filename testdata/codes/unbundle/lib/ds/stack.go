@@ -0,0 +1,25 @@
+package ds
+
+import "github.com/murosan/gollect/testdata/codes/unbundle/lib/mathx"
+
+// Stack is a LIFO stack.
+type Stack struct {
+	items []int
+	max   int
+}
+
+// Push pushes v.
+func (s *Stack) Push(v int) {
+	s.items = append(s.items, v)
+	s.max = mathx.Max(s.max, v)
+}
+
+// Pop pops the last value.
+func (s *Stack) Pop() int {
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+// Len returns the size.
+func (s *Stack) Len() int { return len(s.items) }
@@ -0,0 +1,27 @@
+package mathx
+
+// Max returns the larger one.
+func Max(a, b int) int {
+	if a < b {
+		return b
+	}
+	return a
+}
+
+// Gcd returns the greatest common divisor.
+func Gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Mod is a modulus.
+const Mod = 998244353
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+func main() {
+	s := &Stack{}
+	s.Push(Max(1, 2))
+	fmt.Println(s.Pop(), Gcd(4, 6), abs(-1)%Mod, solve())
+}
+
+// solve is not a library function.
+func solve() int {
+	a := []int{3, 1, 2}
+	sort.Ints(a)
+	return a[0]
+}
+
+// Max returns the larger one.
+func Max(a, b int) int {
+	if a < b {
+		return b
+	}
+	return a
+}
+
+// Gcd is modified.
+func Gcd(a, b int) int {
+	if b == 0 {
+		return a
+	}
+	return Gcd(b, a%b)
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Mod is a modulus.
+const Mod = 998244353
+
+// Stack is a LIFO stack.
+type Stack struct {
+	items []int
+	max   int
+}
+
+// Push pushes v.
+func (s *Stack) Push(v int) {
+	s.items = append(s.items, v)
+	s.max = Max(s.max, v)
+}
+
+// Pop pops the last value.
+func (s *Stack) Pop() int {
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
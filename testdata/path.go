@@ -16,11 +16,38 @@ var (
 	FilePaths = struct {
 		Parse,
 		Write1,
-		Write2 string
+		Write2,
+		Exclude,
+		Comments,
+		Unbundle,
+		UnbundleLib,
+		Stats,
+		StatsAllow,
+		StatsError,
+		Lint,
+		Check,
+		Alias,
+		Precompute,
+		Directive string
 	}{
-		Parse:  j(codes, "parse", "main.go"),
-		Write1: j(codes, "writeone", "*.go"),
-		Write2: j(codes, "writetwo", "*.go"),
+		Parse:    j(codes, "parse", "main.go"),
+		Write1:   j(codes, "writeone", "*.go"),
+		Write2:   j(codes, "writetwo", "*.go"),
+		Exclude:  j(codes, "exclude", "main.go"),
+		Comments: j(codes, "comments", "main.go"),
+
+		Unbundle:    j(codes, "unbundle", "main.go"),
+		UnbundleLib: j(codes, "unbundle", "lib"),
+
+		Stats:      j(codes, "stats"),
+		StatsAllow: j(codes, "statsallow"),
+		StatsError: j(codes, "statserror"),
+		Lint:       j(codes, "lint"),
+		Check:      j(codes, "check"),
+		Alias:      j(codes, "alias", "main.go"),
+
+		Precompute: j(codes, "precompute"),
+		Directive:  j(codes, "directive"),
 	}
 
 	pkgBase = "github.com/murosan/gollect/testdata/codes"
@@ -0,0 +1,618 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// Unbundle is the inverse of Write. It reads the bundled main file of
+// config.InputFile, and removes the top-level declarations found in the
+// library packages under libDir. References to them are rewritten to
+// qualified selectors, and the imports are added.
+// The result is written to config.OutputPaths.
+func Unbundle(config *Config, libDir string) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	setThirdPartyPackagePathPrefixes(config.ThirdPartyPackagePathPrefixes)
+	setBuildContext(config.GOOS, config.GOARCH)
+
+	src, err := unbundle(config.InputFile, libDir)
+	if err != nil {
+		return err
+	}
+
+	w := &writer{config: config}
+	if _, err := w.Write(src); err != nil {
+		return err
+	}
+	return w.writeForeach()
+}
+
+// libraryDecl is a top-level declaration of library packages.
+// A type has its methods.
+type libraryDecl struct {
+	pkg        *packages.Package
+	name       string
+	normalized string
+	methods    map[string]string // name → normalized
+}
+
+// unbundledDecl is a top-level declaration of the bundled file matched
+// with a library declaration.
+type unbundledDecl struct {
+	lib  *libraryDecl
+	decl ast.Decl // *ast.FuncDecl or *ast.GenDecl with a spec
+	spec ast.Spec // nil for functions
+	obj  types.Object
+}
+
+func unbundle(input, libDir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, input, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := &types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("main", fset, []*ast.File{file}, info)
+	if err != nil {
+		return nil, fmt.Errorf("types.Conf check: %w", err)
+	}
+
+	libs, err := loadLibraryDecls(libDir)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := matchDecls(file, info, libs)
+
+	// unexported declarations cannot be referred from main.
+	// they are left in main while any of them is referred.
+	for {
+		refs := referredObjects(file, info, matched)
+		changed := false
+		for obj := range matched {
+			if refs[obj] && !obj.Exported() {
+				delete(matched, obj)
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	rewriteUnbundled(fset, file, info, pkg.Scope(), matched)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("format: %w", err)
+	}
+
+	// separates the imports of libraries from standard ones
+	src, err := imports.Process(input, buf.Bytes(), &imports.Options{
+		Comments:   true,
+		TabIndent:  true,
+		TabWidth:   8,
+		FormatOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("format imports: %w", err)
+	}
+	return src, nil
+}
+
+// loadLibraryDecls loads all packages under the directory, and returns
+// their top-level declarations keyed by name.
+func loadLibraryDecls(dir string) (map[string][]*libraryDecl, error) {
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Dir:        dir,
+		Env:        buildEnv(),
+		BuildFlags: buildFlags(),
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	decls := make(map[string][]*libraryDecl)
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" || len(pkg.Errors) > 0 {
+			continue
+		}
+
+		typeDecls := make(map[string]*libraryDecl)
+		var methods []*ast.FuncDecl
+
+		for _, file := range pkg.Syntax {
+			flatten := userPackageSelector(file)
+
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					if decl.Recv != nil {
+						methods = append(methods, decl)
+						continue
+					}
+					d := &libraryDecl{pkg: pkg, name: decl.Name.Name, normalized: normalize(decl, flatten)}
+					decls[d.name] = append(decls[d.name], d)
+
+				case *ast.GenDecl:
+					for _, spec := range decl.Specs {
+						for _, name := range specNames(spec) {
+							d := &libraryDecl{pkg: pkg, name: name, normalized: normalize(spec, flatten)}
+							if _, ok := spec.(*ast.TypeSpec); ok {
+								d.methods = make(map[string]string)
+								typeDecls[name] = d
+							}
+							decls[name] = append(decls[name], d)
+						}
+					}
+				}
+			}
+		}
+
+		for _, m := range methods {
+			file := fileOf(pkg.Syntax, m)
+			if t, ok := typeDecls[recvTypeName(m.Recv.List[0].Type)]; ok {
+				t.methods[m.Name.Name] = normalize(m, userPackageSelector(file))
+			}
+		}
+	}
+
+	return decls, nil
+}
+
+// matchDecls returns top-level declarations of the file which have the
+// same name and the same normalized AST as library declarations.
+// A type is matched only if all its methods are matched.
+func matchDecls(file *ast.File, info *types.Info, libs map[string][]*libraryDecl) map[types.Object]*unbundledDecl {
+	noFlatten := func(*ast.SelectorExpr) bool { return false }
+
+	methods := make(map[string][]*ast.FuncDecl)
+	for _, decl := range file.Decls {
+		if f, ok := decl.(*ast.FuncDecl); ok && f.Recv != nil {
+			name := recvTypeName(f.Recv.List[0].Type)
+			methods[name] = append(methods[name], f)
+		}
+	}
+
+	find := func(name, normalized string, ms []*ast.FuncDecl) *libraryDecl {
+		var found []*libraryDecl
+	candidates:
+		for _, lib := range libs[name] {
+			if lib.normalized != normalized {
+				continue
+			}
+			for _, m := range ms {
+				if lib.methods[m.Name.Name] != normalize(m, noFlatten) {
+					continue candidates
+				}
+			}
+			found = append(found, lib)
+		}
+
+		if len(found) > 1 {
+			var paths []string
+			for _, f := range found {
+				paths = append(paths, f.pkg.PkgPath)
+			}
+			color.New(color.FgYellow).Fprintf(
+				WarnOutput,
+				"[warn] `%s` is found in multiple packages %v, %s is used\n", name, paths, paths[0],
+			)
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		return found[0]
+	}
+
+	matched := make(map[types.Object]*unbundledDecl)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil || decl.Name.Name == "main" || decl.Name.Name == "init" {
+				continue
+			}
+			if lib := find(decl.Name.Name, normalize(decl, noFlatten), nil); lib != nil {
+				obj := info.Defs[decl.Name]
+				matched[obj] = &unbundledDecl{lib: lib, decl: decl, obj: obj}
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				names := specNames(spec)
+				if len(names) != 1 || names[0] == "_" {
+					// multiple names in a spec are not supported
+					continue
+				}
+
+				var ms []*ast.FuncDecl
+				var id *ast.Ident
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					ms, id = methods[names[0]], spec.Name
+				case *ast.ValueSpec:
+					id = spec.Names[0]
+				default:
+					continue
+				}
+
+				if lib := find(names[0], normalize(spec, noFlatten), ms); lib != nil {
+					obj := info.Defs[id]
+					matched[obj] = &unbundledDecl{lib: lib, decl: decl, spec: spec, obj: obj}
+				}
+			}
+		}
+	}
+	return matched
+}
+
+// referredObjects returns matched objects referred from declarations
+// left in main.
+func referredObjects(file *ast.File, info *types.Info, matched map[types.Object]*unbundledDecl) map[types.Object]bool {
+	refs := make(map[types.Object]bool)
+	for _, decl := range file.Decls {
+		for _, node := range leftNodes(decl, matched) {
+			ast.Inspect(node, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if obj := info.Uses[id]; obj != nil && matched[obj] != nil {
+						refs[obj] = true
+					}
+				}
+				return true
+			})
+		}
+	}
+	return refs
+}
+
+// leftNodes returns nodes of the decl left in main.
+func leftNodes(decl ast.Decl, matched map[types.Object]*unbundledDecl) (nodes []ast.Node) {
+	isMatched := func(d ast.Decl, s ast.Spec) bool {
+		for _, u := range matched {
+			if u.decl == d && u.spec == s {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil {
+			// methods are moved with the type
+			name := recvTypeName(decl.Recv.List[0].Type)
+			for _, u := range matched {
+				if _, ok := u.obj.(*types.TypeName); ok && u.obj.Name() == name {
+					return nil
+				}
+			}
+			return []ast.Node{decl}
+		}
+		if !isMatched(decl, nil) {
+			return []ast.Node{decl}
+		}
+
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			if !isMatched(decl, spec) {
+				nodes = append(nodes, spec)
+			}
+		}
+	}
+	return
+}
+
+// rewriteUnbundled removes matched declarations from the file, rewrites
+// references to them and fixes imports.
+func rewriteUnbundled(
+	fset *token.FileSet,
+	file *ast.File,
+	info *types.Info,
+	scope *types.Scope,
+	matched map[types.Object]*unbundledDecl,
+) {
+	// ranges of removed declarations and specs including their comments
+	var removed [][2]token.Pos
+	remove := func(node ast.Node) {
+		start, end := node.Pos(), node.End()
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			if n.Doc != nil {
+				start = n.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if n.Doc != nil {
+				start = n.Doc.Pos()
+			}
+		case *ast.ValueSpec:
+			if n.Doc != nil {
+				start = n.Doc.Pos()
+			}
+			if n.Comment != nil {
+				end = n.Comment.End()
+			}
+		case *ast.TypeSpec:
+			if n.Doc != nil {
+				start = n.Doc.Pos()
+			}
+			if n.Comment != nil {
+				end = n.Comment.End()
+			}
+		}
+		removed = append(removed, [2]token.Pos{start, end})
+	}
+
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		nodes := leftNodes(decl, matched)
+		if len(nodes) == 0 {
+			remove(decl)
+			continue
+		}
+
+		if g, ok := decl.(*ast.GenDecl); ok && g.Tok != token.IMPORT {
+			left := make(map[ast.Node]bool)
+			for _, n := range nodes {
+				left[n] = true
+			}
+			var specs []ast.Spec
+			for _, spec := range g.Specs {
+				if left[spec] {
+					specs = append(specs, spec)
+				} else {
+					remove(spec)
+				}
+			}
+			g.Specs = specs
+		}
+		decls = append(decls, decl)
+	}
+	file.Decls = decls
+
+	var comments []*ast.CommentGroup
+	for _, c := range file.Comments {
+		inside := false
+		for _, r := range removed {
+			if r[0] <= c.Pos() && c.End() <= r[1] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			comments = append(comments, c)
+		}
+	}
+	file.Comments = comments
+
+	// qualify references
+	names := make(map[string]string) // package path → name in main
+	used := make(map[string]bool)
+	astutil.Apply(file, func(cr *astutil.Cursor) bool {
+		id, ok := cr.Node().(*ast.Ident)
+		if !ok {
+			return true
+		}
+		u := matched[info.Uses[id]]
+		if u == nil {
+			return true
+		}
+		if _, ok := cr.Parent().(*ast.SelectorExpr); ok && cr.Name() == "Sel" {
+			return true
+		}
+
+		path := u.lib.pkg.PkgPath
+		name, ok := names[path]
+		if !ok {
+			name = importName(file, scope, u.lib.pkg.Name, names)
+			names[path] = name
+		}
+		used[path] = true
+		cr.Replace(&ast.SelectorExpr{X: ast.NewIdent(name), Sel: ast.NewIdent(id.Name)})
+		return true
+	}, nil)
+
+	var paths []string
+	for path := range used {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if name := names[path]; name == pathName(path) {
+			astutil.AddImport(fset, file, path)
+		} else {
+			astutil.AddNamedImport(fset, file, name, path)
+		}
+	}
+
+	// remove imports used only by the removed declarations
+	for _, spec := range append([]*ast.ImportSpec(nil), file.Imports...) {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if used[path] {
+			continue
+		}
+		if !astutil.UsesImport(file, path) {
+			name := ""
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name != "_" && name != "." {
+				astutil.DeleteNamedImport(fset, file, name, path)
+			}
+		}
+	}
+
+	var replaced []string
+	for _, u := range matched {
+		replaced = append(replaced, fmt.Sprintf("`%s` is replaced by %s.%s", u.obj.Name(), u.lib.pkg.PkgPath, u.obj.Name()))
+	}
+	sort.Strings(replaced)
+	for _, s := range replaced {
+		fmt.Fprintf(WarnOutput, "[info] %s\n", s)
+	}
+}
+
+// importName returns a name of the package not conflicting with
+// the declarations and imports in the file.
+func importName(file *ast.File, scope *types.Scope, name string, names map[string]string) string {
+	taken := make(map[string]bool)
+	for _, n := range names {
+		taken[n] = true
+	}
+	for _, spec := range file.Imports {
+		if spec.Name != nil {
+			taken[spec.Name.Name] = true
+		} else {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			taken[pathName(path)] = true
+		}
+	}
+	for _, n := range scope.Names() {
+		taken[n] = true
+	}
+
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+func pathName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// userPackageSelector returns a function reporting whether the selector
+// refers to a user package, which is removed by bundling.
+func userPackageSelector(file *ast.File) func(*ast.SelectorExpr) bool {
+	names := make(map[string]bool)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if isBuiltinPackage(path) {
+			continue
+		}
+		if spec.Name != nil {
+			names[spec.Name.Name] = true
+		} else {
+			names[pathName(path)] = true
+		}
+	}
+
+	return func(sel *ast.SelectorExpr) bool {
+		id, ok := sel.X.(*ast.Ident)
+		return ok && id.Obj == nil && names[id.Name]
+	}
+}
+
+// normalize returns the string representing the structure of the node.
+// Comments and positions are ignored, and the selectors reported by
+// flatten are treated as their Sel.
+func normalize(node ast.Node, flatten func(*ast.SelectorExpr) bool) string {
+	var b strings.Builder
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil:
+			b.WriteString(")")
+			return false
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		case *ast.SelectorExpr:
+			if flatten(n) {
+				fmt.Fprintf(&b, "(%T %s)", n.Sel, n.Sel.Name)
+				return false
+			}
+		}
+
+		fmt.Fprintf(&b, "(%T", n)
+		switch n := n.(type) {
+		case *ast.Ident:
+			b.WriteString(" " + n.Name)
+		case *ast.BasicLit:
+			b.WriteString(" " + n.Value)
+		case *ast.BinaryExpr:
+			b.WriteString(" " + n.Op.String())
+		case *ast.UnaryExpr:
+			b.WriteString(" " + n.Op.String())
+		case *ast.AssignStmt:
+			b.WriteString(" " + n.Tok.String())
+		case *ast.IncDecStmt:
+			b.WriteString(" " + n.Tok.String())
+		case *ast.BranchStmt:
+			b.WriteString(" " + n.Tok.String())
+		case *ast.RangeStmt:
+			b.WriteString(" " + n.Tok.String())
+		case *ast.ChanType:
+			fmt.Fprintf(&b, " %d", n.Dir)
+		case *ast.TypeSpec:
+			fmt.Fprintf(&b, " %t", n.Assign.IsValid())
+		}
+		return true
+	})
+	return b.String()
+}
+
+func specNames(spec ast.Spec) (names []string) {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		names = append(names, spec.Name.Name)
+	case *ast.ValueSpec:
+		for _, id := range spec.Names {
+			names = append(names, id.Name)
+		}
+	}
+	return
+}
+
+func recvTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(expr.X)
+	case *ast.ParenExpr:
+		return recvTypeName(expr.X)
+	}
+	return ""
+}
+
+func fileOf(files []*ast.File, node ast.Node) *ast.File {
+	for _, f := range files {
+		if f.FileStart <= node.Pos() && node.End() <= f.FileEnd {
+			return f
+		}
+	}
+	return files[0]
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 murosan. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gollect
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"github.com/murosan/gollect/testdata"
+)
+
+func TestUnbundle(t *testing.T) {
+	var warn bytes.Buffer
+	WarnOutput = &warn
+	defer func() { WarnOutput = os.Stderr }()
+
+	setThirdPartyPackagePathPrefixes([]string{"golang.org/x/exp"})
+
+	// Max, Mod and Stack with its methods are replaced.
+	// Gcd is modified, and abs is unexported, so they are left.
+	want := `package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/murosan/gollect/testdata/codes/unbundle/lib/ds"
+	"github.com/murosan/gollect/testdata/codes/unbundle/lib/mathx"
+)
+
+func main() {
+	s := &ds.Stack{}
+	s.Push(mathx.Max(1, 2))
+	fmt.Println(s.Pop(), Gcd(4, 6), abs(-1)%mathx.Mod, solve())
+}
+
+// solve is not a library function.
+func solve() int {
+	a := []int{3, 1, 2}
+	sort.Ints(a)
+	return a[0]
+}
+
+// Gcd is modified.
+func Gcd(a, b int) int {
+	if b == 0 {
+		return a
+	}
+	return Gcd(b, a%b)
+}
+
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+`
+
+	actual, err := unbundle(testdata.FilePaths.Unbundle, testdata.FilePaths.UnbundleLib)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != want {
+		diff := dmp.New().DiffMain(want, string(actual), true)
+		t.Errorf("\n[diff]\n%s", colorDiff(diff))
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	parse := func(src string) *ast.File {
+		t.Helper()
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	lib := parse(`package lib
+
+import "example.com/other"
+
+// F is F.
+func F() int {
+	return other.G( 1 ) // comment
+}
+`)
+	bundled := parse(`package main
+
+func F() int { return G(1) }
+`)
+
+	a := normalize(lib.Decls[1], userPackageSelector(lib))
+	b := normalize(bundled.Decls[0], func(*ast.SelectorExpr) bool { return false })
+	if a != b {
+		t.Errorf("should be same\n%s\n%s", a, b)
+	}
+
+	modified := parse(`package main
+
+func F() int { return G(2) }
+`)
+	if c := normalize(modified.Decls[0], func(*ast.SelectorExpr) bool { return false }); a == c {
+		t.Error("should be different")
+	}
+}
@@ -12,8 +12,49 @@ func (a Annotation) String() string { return string(a) }
 const (
 	annotationPrefix = "// gollect: "
 
+	// An annotation for any declaration.
+	// The declaration will be left even if it is not used from main.
+	// For method declaration, the method will be left when its receiver
+	// type is used.
+	keep Annotation = annotationPrefix + "keep"
+
 	// An annotation for type declaration.
 	// If exists in doc comment, all receiver methods will be left,
 	// otherwise only the methods called directly will be left.
+	// When method names follow, only the methods will be left.
+	//   // gollect: keep methods Len Less Swap
 	keepMethods Annotation = annotationPrefix + "keep methods"
+
+	// An annotation for any declaration.
+	// Bundling fails if the declaration is used from main.
+	exclude Annotation = annotationPrefix + "exclude"
+
+	// An annotation for function declaration.
+	// The function is treated as an extra entry point as same as main.
+	root Annotation = annotationPrefix + "root"
+
+	// An annotation for package-level variable declaration.
+	// The initializer is evaluated at bundle time, and replaced with
+	// the literal of the value.
+	precompute Annotation = annotationPrefix + "precompute"
+)
+
+// Directive is a file-level directive of the main package, which
+// overrides the config for the solution.
+type Directive string
+
+func (d Directive) String() string { return string(d) }
+
+const (
+	directivePrefix = "//gollect:"
+
+	// A directive replacing the output paths.
+	// Paths are separated by commas.
+	//   //gollect:out clipboard,out/c.go,cmd:xclip -selection clipboard
+	outDirective Directive = directivePrefix + "out"
+
+	// A directive adding package path prefixes treated as same as
+	// builtin packages. Prefixes are separated by commas.
+	//   //gollect:allow gonum.org/v1/gonum
+	allowDirective Directive = directivePrefix + "allow"
 )
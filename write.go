@@ -5,26 +5,84 @@
 package gollect
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/format"
+	"go/token"
 	"io"
+	"sort"
 )
 
+// WriteOptions is options of Write.
+type WriteOptions struct {
+	// omits the package clause.
+	Snippet bool
+
+	// renames the package clause if not empty.
+	PackageName string
+
+	// drops comments, shortens unexported identifiers of library
+	// packages and collapses blank lines.
+	Minify bool
+
+	// decides which comments are left. CommentsAll is used if empty.
+	// CommentsNone is used when minifying.
+	Comments CommentPolicy
+
+	// groups declarations of libraries by package and file with
+	// banner comments.
+	Banners bool
+
+	// writes the version of gollect, revisions of library modules
+	// and the hash of inputs at the head.
+	Header bool
+}
+
 // Write writes filtered and formatted code to io.Writer.
 func Write(w io.Writer, program *Program) error {
 	fset, dset := program.FileSet(), program.DeclSet()
 	iset, pset := program.ImportSet(), program.PackageSet()
 
-	// get head of main package's ast files
+	opts := program.WriteOptions()
+	policy := opts.Comments
+	if opts.Minify {
+		policy = CommentsNone
+	}
+
+	// get the entry package's ast file declaring main function
 	// treat this as base ast
-	mainPackage := pset["main"]
-	main := mainPackage.files[0]
+	dropStrippedComments(fset, pset)
+
+	mainPackage := pset[program.EntryPackage()]
+	main := baseFile(mainPackage)
+	if opts.PackageName != "" && opts.PackageName != main.Name.Name {
+		main.Name.Name = opts.PackageName
+		// the package doc describes the package before renaming
+		dropPackageDoc(main)
+	}
 
 	filter := NewFilter(dset, mainPackage)
 
 	// delete unused codes and all imports from base ast
+	ranges := declRanges(main.Decls)
 	main.Decls = filter.Decls(main.Decls)
+	if err := checkDirectives(fset, main, main.Decls); err != nil {
+		return err
+	}
+	if err := inlineEmbeds(fset, main.Decls); err != nil {
+		return err
+	}
+	if p := policy.forMain(); p == CommentsAll {
+		pruneComments(main, ranges)
+	} else {
+		comments := selectComments(main, main.Decls, p)
+		if p == CommentsDocOnly && main.Doc != nil {
+			comments = append([]*ast.CommentGroup{main.Doc}, comments...)
+		}
+		// not nil, so that the doc comments of nodes are not printed
+		main.Comments = append([]*ast.CommentGroup{}, comments...)
+	}
 	filter.PackageSelectorExpr(main)
 
 	// build new import decl and push it to head of decls
@@ -32,11 +90,15 @@ func Write(w io.Writer, program *Program) error {
 		main.Decls = append([]ast.Decl{ispec}, main.Decls...)
 	}
 
-	if err := format.Node(w, fset, main); err != nil {
-		return fmt.Errorf("format: %w", err)
+	type chunk struct {
+		pkg   *Package
+		file  *ast.File
+		decls []ast.Decl
 	}
 
-	for path, pkg := range pset {
+	var chunks []chunk
+	for _, path := range sortedPackagePaths(pset, program.EntryPackage()) {
+		pkg := pset[path]
 		for _, file := range pkg.files {
 			if file == main {
 				continue
@@ -44,23 +106,187 @@ func Write(w io.Writer, program *Program) error {
 
 			filter := NewFilter(dset, pset[path])
 			decls := filter.Decls(file.Decls)
+			if len(decls) != 0 {
+				if err := checkDirectives(fset, file, decls); err != nil {
+					return err
+				}
+			}
+			if err := inlineEmbeds(fset, decls); err != nil {
+				return err
+			}
 			for _, d := range decls {
 				filter.PackageSelectorExpr(d)
 			}
 
 			if len(decls) != 0 {
-				if _, err := w.Write([]byte("\n")); err != nil {
-					return err
-				}
-				if err := format.Node(w, fset, decls); err != nil {
-					return fmt.Errorf("format: %w", err)
-				}
-				if _, err := w.Write([]byte("\n")); err != nil {
-					return err
-				}
+				chunks = append(chunks, chunk{pkg, file, decls})
 			}
 		}
 	}
 
-	return nil
+	if opts.Minify {
+		Minify(program)
+	}
+
+	// prints without the lines of stripped statements
+	pfset := strippedFileSet(fset, pset)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, pfset, main); err != nil {
+		return fmt.Errorf("format: %w", err)
+	}
+	for _, c := range chunks {
+		comments := selectComments(c.file, c.decls, policy.forLibrary())
+
+		// printed as a file with the package clause, so that the
+		// comments are placed at right positions.
+		var b bytes.Buffer
+		if err := format.Node(&b, pfset, commentedFile(c.file, c.decls, comments)); err != nil {
+			return fmt.Errorf("format: %w", err)
+		}
+		buf.WriteString("\n")
+		if opts.Banners {
+			buf.WriteString(banner(c.pkg.path, fset.File(c.file.Pos()).Name()))
+			buf.WriteString("\n")
+		}
+		buf.Write(removePackageClause(b.Bytes()))
+	}
+
+	src := buf.Bytes()
+	if opts.Minify {
+		b, err := format.Source(collapseBlankLines(src))
+		if err != nil {
+			return fmt.Errorf("format: %w", err)
+		}
+		src = b
+	}
+	if opts.Snippet {
+		src = removePackageClause(src)
+	}
+	if opts.Header {
+		src = append([]byte(header(program)+"\n"), src...)
+	}
+
+	_, err := w.Write(src)
+	return err
+}
+
+// baseFile returns the file declaring main function.
+// If there is no such file, returns the head of files.
+func baseFile(pkg *Package) *ast.File {
+	for _, file := range pkg.files {
+		for _, decl := range file.Decls {
+			if f, ok := decl.(*ast.FuncDecl); ok && f.Recv == nil && f.Name.Name == "main" {
+				return file
+			}
+		}
+	}
+	return pkg.files[0]
+}
+
+// sortedPackagePaths returns package paths in the order of output.
+// The entry package comes first, and others are sorted by path.
+func sortedPackagePaths(pset PackageSet, entry string) []string {
+	paths := make([]string, 0, len(pset))
+	for path := range pset {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if (paths[i] == entry) != (paths[j] == entry) {
+			return paths[i] == entry
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// removePackageClause removes the package clause and blank lines
+// following it from formatted source.
+func removePackageClause(src []byte) []byte {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("package ")) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(lines) && len(bytes.TrimSpace(lines[j])) == 0 {
+			j++
+		}
+		return bytes.Join(append(lines[:i:i], lines[j:]...), nil)
+	}
+	return src
+}
+
+// declRanges returns ranges of declarations including their doc comments.
+func declRanges(decls []ast.Decl) map[ast.Decl][2]token.Pos {
+	m := make(map[ast.Decl][2]token.Pos)
+	for _, d := range decls {
+		start := d.Pos()
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		m[d] = [2]token.Pos{start, d.End()}
+	}
+	return m
+}
+
+// pruneComments removes comments of the declarations removed from file.
+// The ranges are of declarations before filtering.
+func pruneComments(file *ast.File, ranges map[ast.Decl][2]token.Pos) {
+	left := make(map[ast.Decl]bool)
+	for _, d := range file.Decls {
+		left[d] = true
+	}
+
+	var removed [][2]token.Pos
+	for d, r := range ranges {
+		if !left[d] {
+			removed = append(removed, r)
+		}
+	}
+
+	comments := file.Comments[:0]
+	for _, c := range file.Comments {
+		removeCommentsIf(c, isFileLevel)
+		if len(c.List) == 0 {
+			// all comments are annotations or file-level directives
+			continue
+		}
+
+		inside := false
+		for _, r := range removed {
+			if r[0] <= c.Pos() && c.End() <= r[1] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			comments = append(comments, c)
+		}
+	}
+	file.Comments = comments
+}
+
+// dropPackageDoc removes the package doc comment of file.
+func dropPackageDoc(file *ast.File) {
+	if file.Doc == nil {
+		return
+	}
+
+	comments := file.Comments[:0]
+	for _, c := range file.Comments {
+		if c != file.Doc {
+			comments = append(comments, c)
+		}
+	}
+	file.Comments = comments
+	file.Doc = nil
 }
@@ -7,6 +7,7 @@ package gollect
 import (
 	"bytes"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/murosan/gollect/testdata"
@@ -91,3 +92,24 @@ func FuncB() {
 		}
 	}
 }
+
+func TestWriteDirectiveError(t *testing.T) {
+	cases := []struct {
+		dir, want string
+	}{
+		{dir: "linkname", want: "lib.go:5:1: //go:linkname of nanotime is not supported"},
+		{dir: "cgo", want: "lib.go:3:1: //go:cgo_import_dynamic is not supported"},
+	}
+
+	for _, c := range cases {
+		program := NewProgram()
+		ParseAll(program, "main", []string{filepath.Join(testdata.FilePaths.Directive, c.dir, "main.go")})
+		AnalyzeForeach(program, "main", "main")
+
+		var buf bytes.Buffer
+		err := Write(&buf, program)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: want error containing %q, but got %v", c.dir, c.want, err)
+		}
+	}
+}